@@ -1,10 +1,12 @@
 package astar
 
 import (
-	"container/heap"
 	"context"
 	"errors"
+	"log/slog"
+	"math"
 	"runtime"
+	"time"
 )
 
 // Graph is generic over node type N.
@@ -20,7 +22,8 @@ type Neighbor[NodeType comparable] struct {
 	Cost float64
 }
 
-// Heuristic returns the estimated cost from node a to node b
+// Heuristic returns the estimated cost from node a to node b. Estimates must
+// not be negative or NaN; +Inf marks a node that cannot reach the goal.
 type Heuristic[NodeType comparable] func(from NodeType, to NodeType) float64
 
 // Result contains the outcome of a search
@@ -29,11 +32,35 @@ type Result[NodeType comparable] struct {
 	TotalCost     float64
 	ExpandedNodes int
 	Found         bool
+	// Partial is set when the search ran out of budget. Path then leads to
+	// the open node with the lowest heuristic estimate and TotalCost is the
+	// cost of that partial path.
+	Partial bool
+	Stats   Statistics
 }
 
 // Options defines parameters for the search.
 type Options struct {
 	NumberOfWorkers int
+	OpenList        OpenListKind
+	BucketWidth     float64
+	TieBreak        TieBreak
+	TieBreakFunc    func(a, b TieBreakEntry) bool
+	Deterministic   bool
+	MaxExpansions   int
+	MaxCost         float64
+	Deadline        time.Time
+	Logger          *slog.Logger
+	LogInterval     time.Duration
+	// Progress and ProgressInterval are set by WithProgress.
+	Progress         func(Progress)
+	ProgressInterval time.Duration
+	DeltaSnapshots   bool
+	FrontierSize     int
+	HistoryLimit     int
+
+	// tracers holds Tracer values of any node type, see WithTracer.
+	tracers []any
 }
 
 // Option is a function that modifies Options.
@@ -44,7 +71,51 @@ func WithWorkers(numberOfWorkers int) Option {
 	return func(options *Options) { options.NumberOfWorkers = numberOfWorkers }
 }
 
+// WithOpenList selects the priority queue implementation for the open set.
+func WithOpenList(kind OpenListKind) Option {
+	return func(options *Options) { options.OpenList = kind }
+}
+
+// WithBucketWidth sets the f-cost range covered by each bucket of
+// OpenListBucketQueue. The default width is 1.
+func WithBucketWidth(width float64) Option {
+	return func(options *Options) { options.BucketWidth = width }
+}
+
+// WithDeterministic makes the search apply the relaxations of each expanded
+// node in the order Graph.Neighbors returned them, regardless of which worker
+// finishes first. Together with a deterministic graph and heuristic, the same
+// query then always expands the same nodes and returns the same Path.
+func WithDeterministic() Option {
+	return func(options *Options) { options.Deterministic = true }
+}
+
+// defaultOptions returns the Options used when no Option is given.
+func defaultOptions() Options {
+	return Options{
+		NumberOfWorkers: runtime.NumCPU(),
+		BucketWidth:     1,
+		MaxCost:         math.Inf(1),
+	}
+}
+
+// applyOptions returns searchOptions modified by options.
+func applyOptions(searchOptions Options, options []Option) Options {
+	for _, option := range options {
+		option(&searchOptions)
+	}
+	return searchOptions
+}
+
 // Search executes the concurrent A* search algorithm.
+//
+// Search keeps its state in maps, so a query only allocates for the nodes it
+// reaches. For graphs that implement Indexer, SearchIndexed and Engine keep
+// it in slices sized for the whole graph, allocated once and reused across
+// queries.
+//
+// Search starts its own worker pool and stops it before returning. Use an
+// Engine to keep one pool alive across many queries.
 func Search[NodeType comparable](
 	contextObject context.Context,
 	graph Graph[NodeType],
@@ -53,145 +124,127 @@ func Search[NodeType comparable](
 	heuristic Heuristic[NodeType],
 	options ...Option,
 ) (Result[NodeType], error) {
+	searchOptions := applyOptions(defaultOptions(), options)
+	pool := newWorkerPool[NodeType](searchOptions.NumberOfWorkers)
+	defer pool.close()
 
-	// --- Apply options ---
-	searchOptions := Options{
-		NumberOfWorkers: runtime.NumCPU(),
-	}
-	for _, option := range options {
-		option(&searchOptions)
-	}
+	return search(contextObject, graph, newMapState[NodeType](), pool, startNode, goalNode, heuristic, searchOptions)
+}
 
-	// --- Initialize state ---
-	openSet := make(PriorityQueue[NodeType], 0)
-	heap.Init(&openSet)
+// SearchIndexed is like Search but keeps its state in the given IndexedState,
+// so that the slices allocated by one query are reused by the next.
+func SearchIndexed[NodeType comparable](
+	contextObject context.Context,
+	graph IndexedGraph[NodeType],
+	state *IndexedState[NodeType],
+	startNode NodeType,
+	goalNode NodeType,
+	heuristic Heuristic[NodeType],
+	options ...Option,
+) (Result[NodeType], error) {
+	searchOptions := applyOptions(defaultOptions(), options)
+	pool := newWorkerPool[NodeType](searchOptions.NumberOfWorkers)
+	defer pool.close()
+
+	state.begin(graph)
+	return search(contextObject, graph, state, pool, startNode, goalNode, heuristic, searchOptions)
+}
+
+// search runs one query on pool until it finds the goal, exhausts the open
+// set or fails.
+func search[NodeType comparable](
+	contextObject context.Context,
+	graph Graph[NodeType],
+	state nodeState[NodeType],
+	pool *workerPool[NodeType],
+	startNode NodeType,
+	goalNode NodeType,
+	heuristic Heuristic[NodeType],
+	searchOptions Options,
+) (Result[NodeType], error) {
+	// Cancelling on return releases workers still holding tasks of this query.
+	contextObject, cancel := context.WithCancel(contextObject)
+	defer cancel()
+	o := newOrchestrator(graph, state, pool, startNode, goalNode, heuristic, searchOptions)
+	return o.run(contextObject, nil)
+}
 
-	startItem := &PriorityQueueItem[NodeType]{
-		Node:   startNode,
-		GScore: 0.0,
-		FCost:  heuristic(startNode, goalNode),
+// run drives the orchestrator until it finds the goal, exhausts the open set
+// or fails. A non-nil gate is entered around every expansion, which lets a
+// SearchHandle pause the search and inspect it between expansions.
+func (o *orchestrator[NodeType]) run(contextObject context.Context, gate *pauseGate) (Result[NodeType], error) {
+	searchStart := time.Now()
+
+	// --- Initialize state ---
+	gate.enter(contextObject)
+	o.logStart(contextObject)
+	err := o.begin()
+	if err != nil {
+		o.logEnd(contextObject, false, 0, err)
 	}
-	heap.Push(&openSet, startItem)
-
-	cameFrom := make(map[NodeType]NodeType)
-	pathCostFromStart := map[NodeType]float64{startNode: 0.0}
-	closedSet := make(map[NodeType]bool)
-	openSetMap := make(map[NodeType]*PriorityQueueItem[NodeType])
-	openSetMap[startNode] = startItem
-
-	// Channels for communication
-	expandTaskChannel := make(chan ExpandTask[NodeType])
-	relaxProposalChannel := make(chan RelaxProposal[NodeType])
-
-	// --- Start worker pool ---
-	for i := 0; i < searchOptions.NumberOfWorkers; i++ {
-		go func() {
-			for {
-				select {
-				case <-contextObject.Done():
-					return
-				case task := <-expandTaskChannel:
-					tentativeG := task.CurrentGScore + task.Neighbor.Cost
-					f := tentativeG + task.HeuristicFunc(task.Neighbor.ID, task.GoalNode)
-					proposal := RelaxProposal[NodeType]{
-						FromNode: task.FromNode,
-						ToNode:   task.Neighbor.ID,
-						GScore:   tentativeG,
-						FCost:    f,
-					}
-					relaxProposalChannel <- proposal
-				}
-			}
-		}()
+	gate.leave()
+	if err != nil {
+		return Result[NodeType]{}, err
+	}
+	finish := func(result Result[NodeType], err error) (Result[NodeType], error) {
+		o.logEnd(contextObject, result.Found, result.TotalCost, err)
+		o.reportDone(result.Found, result.TotalCost)
+		result.ExpandedNodes = o.expandedNodes
+		result.Stats = o.stats
+		result.Stats.WallTime = time.Since(searchStart)
+		return result, err
 	}
 
 	// --- Orchestrator loop ---
-	expandedNodes := 0
 	for {
-		if openSet.Len() == 0 {
-			return Result[NodeType]{
-				Path:          nil,
-				TotalCost:     0,
-				ExpandedNodes: expandedNodes,
-				Found:         false,
-			}, errors.New("no path found")
+		gate.enter(contextObject)
+		result, done, err := o.advance(contextObject)
+		if done {
+			result, err = finish(result, err)
 		}
-
-		currentItem := heap.Pop(&openSet).(*PriorityQueueItem[NodeType])
-		currentNode := currentItem.Node
-		delete(openSetMap, currentNode)
-
-		// Skip if already closed
-		if closedSet[currentNode] {
-			continue
-		}
-		closedSet[currentNode] = true
-		expandedNodes++
-
-		// Goal check
-		if currentNode == goalNode {
-			return Result[NodeType]{
-				Path:          reconstructPath(cameFrom, currentNode, startNode),
-				TotalCost:     currentItem.GScore,
-				ExpandedNodes: expandedNodes,
-				Found:         true,
-			}, nil
-		}
-
-		// Send tasks to workers for each neighbor
-		neighbors := graph.Neighbors(currentNode)
-		for _, neighbor := range neighbors {
-			task := ExpandTask[NodeType]{
-				FromNode:      currentNode,
-				Neighbor:      neighbor,
-				CurrentGScore: currentItem.GScore,
-				GoalNode:      goalNode,
-				HeuristicFunc: heuristic,
-			}
-			expandTaskChannel <- task
+		gate.leave()
+		if done {
+			return result, err
 		}
+	}
+}
 
-		// Collect proposals for all neighbors of current node
-		for i := 0; i < len(neighbors); i++ {
-			select {
-			case <-contextObject.Done():
-				return Result[NodeType]{}, contextObject.Err()
-			case proposal := <-relaxProposalChannel:
-				if closedSet[proposal.ToNode] {
-					continue
-				}
-				currentG, exists := pathCostFromStart[proposal.ToNode]
-				if !exists || proposal.GScore < currentG {
-					pathCostFromStart[proposal.ToNode] = proposal.GScore
-					cameFrom[proposal.ToNode] = proposal.FromNode
-					if item, inOpen := openSetMap[proposal.ToNode]; !inOpen {
-						item = &PriorityQueueItem[NodeType]{
-							Node:   proposal.ToNode,
-							GScore: proposal.GScore,
-							FCost:  proposal.FCost,
-						}
-						heap.Push(&openSet, item)
-						openSetMap[proposal.ToNode] = item
-					} else if proposal.FCost < item.FCost {
-						item.GScore = proposal.GScore
-						item.FCost = proposal.FCost
-						heap.Fix(&openSet, item.IndexInQueue)
-					}
-				}
-			}
-		}
+// advance expands one node. It reports done with the result of the search
+// once the goal is found, the open set is exhausted or an error occurred.
+func (o *orchestrator[NodeType]) advance(contextObject context.Context) (Result[NodeType], bool, error) {
+	currentItem, outcome, err := o.expandNext(contextObject)
+	if errors.Is(err, ErrBudgetExceeded) {
+		return o.partialResult(), true, err
+	}
+	if err != nil {
+		return Result[NodeType]{}, true, err
+	}
+	switch outcome {
+	case expansionExhausted:
+		return Result[NodeType]{
+			Path:      nil,
+			TotalCost: 0,
+			Found:     false,
+		}, true, ErrNoPath
+	case expansionFound:
+		return Result[NodeType]{
+			Path:      o.path(currentItem.Node),
+			TotalCost: currentItem.GScore,
+			Found:     true,
+		}, true, nil
 	}
+	return Result[NodeType]{}, false, nil
 }
 
-// reconstructPath is internal to the orchestrator.
-func reconstructPath[NodeType comparable](
-	cameFrom map[NodeType]NodeType,
+// reconstructStatePath walks the predecessors recorded in state back to start.
+func reconstructStatePath[NodeType comparable](
+	state nodeState[NodeType],
 	current NodeType,
 	start NodeType,
 ) []NodeType {
 	path := []NodeType{current}
 	for current != start {
-		previousNode, exists := cameFrom[current]
+		previousNode, exists := state.parent(current)
 		if !exists {
 			break
 		}
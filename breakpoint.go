@@ -0,0 +1,109 @@
+package astar
+
+import "slices"
+
+// Breakpoint reports whether a step should stop RunUntil and StepN. It sees
+// the snapshot of every step without the Open, Closed, CameFrom and Scores
+// maps and without the Frontier, which are only built for the step that is
+// returned.
+type Breakpoint[NodeType comparable] func(snapshot StepSnapshot[NodeType]) bool
+
+// BreakOnOpened breaks when node enters the open set.
+func BreakOnOpened[NodeType comparable](node NodeType) Breakpoint[NodeType] {
+	return func(snapshot StepSnapshot[NodeType]) bool {
+		if snapshot.Delta == nil {
+			return false
+		}
+		return slices.ContainsFunc(snapshot.Delta.Added, func(added StepNode[NodeType]) bool {
+			return added.Node == node
+		})
+	}
+}
+
+// BreakOnExpanded breaks when node is expanded.
+func BreakOnExpanded[NodeType comparable](node NodeType) Breakpoint[NodeType] {
+	return func(snapshot StepSnapshot[NodeType]) bool {
+		return snapshot.Delta != nil && slices.Contains(snapshot.Delta.Closed, node)
+	}
+}
+
+// BreakOnFAbove breaks when the expanded node has an f-cost above limit.
+func BreakOnFAbove[NodeType comparable](limit float64) Breakpoint[NodeType] {
+	return func(snapshot StepSnapshot[NodeType]) bool {
+		return snapshot.Delta != nil && len(snapshot.Delta.Closed) > 0 && snapshot.CurrentScore.FCost > limit
+	}
+}
+
+// breakpointEntry is a breakpoint set on a Stepper.
+type breakpointEntry[NodeType comparable] struct {
+	id         int
+	breakpoint Breakpoint[NodeType]
+}
+
+// AddBreakpoint sets a breakpoint checked by RunUntil and StepN after every
+// step. It returns an id for RemoveBreakpoint.
+func (s *Stepper[NodeType]) AddBreakpoint(breakpoint Breakpoint[NodeType]) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextBreakpointID++
+	s.breakpoints = append(s.breakpoints, breakpointEntry[NodeType]{id: s.nextBreakpointID, breakpoint: breakpoint})
+	return s.nextBreakpointID
+}
+
+// RemoveBreakpoint removes the breakpoint with the given id.
+func (s *Stepper[NodeType]) RemoveBreakpoint(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.breakpoints = slices.DeleteFunc(s.breakpoints, func(entry breakpointEntry[NodeType]) bool {
+		return entry.id == id
+	})
+}
+
+// RunUntil steps until condition returns true, a breakpoint fires, the
+// search ends or a step fails, and returns the snapshot of the last step.
+// A nil condition runs until a breakpoint or the end of the search. Only
+// the last snapshot copies the whole state, so the intermediate steps run
+// at close to Search speed.
+func (s *Stepper[NodeType]) RunUntil(condition Breakpoint[NodeType]) (StepSnapshot[NodeType], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.run(-1, condition)
+}
+
+// StepN takes up to n steps, stopping early like RunUntil at a breakpoint or
+// at the end of the search, and returns the snapshot of the last step.
+func (s *Stepper[NodeType]) StepN(n int) (StepSnapshot[NodeType], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n <= 0 {
+		return s.snapshot(), nil
+	}
+	return s.run(n, nil)
+}
+
+// run takes up to limit steps, or without limit when it is negative.
+func (s *Stepper[NodeType]) run(limit int, condition Breakpoint[NodeType]) (StepSnapshot[NodeType], error) {
+	wasDone := s.done
+	for taken := 1; ; taken++ {
+		snapshot, err := s.advance()
+		s.microEvents = s.microEvents[:0]
+		if err != nil || snapshot.Done || taken == limit ||
+			(condition != nil && condition(snapshot)) || s.breakpointHit(snapshot) {
+			s.complete(&snapshot)
+			if !wasDone {
+				s.notify(snapshot)
+			}
+			return snapshot, err
+		}
+		s.notify(snapshot)
+	}
+}
+
+func (s *Stepper[NodeType]) breakpointHit(snapshot StepSnapshot[NodeType]) bool {
+	for _, entry := range s.breakpoints {
+		if entry.breakpoint(snapshot) {
+			return true
+		}
+	}
+	return false
+}
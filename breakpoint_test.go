@@ -0,0 +1,114 @@
+package astar
+
+import (
+	"context"
+	"testing"
+)
+
+// TestBreakpoints checks that each kind of breakpoint, and a RunUntil
+// condition, stops on the step a plain Step loop shows it should.
+func TestBreakpoints(t *testing.T) {
+	grid := newTestGrid(30, 30, 0.3, 4)
+	start, goal := testPoint{0, 0}, testPoint{29, 29}
+	newStepper := func() *Stepper[testPoint] {
+		return NewStepper(context.Background(), grid, start, goal, manhattan, WithWorkers(2), WithDeterministic())
+	}
+
+	// Step through once, keeping every full snapshot.
+	reference := newStepper()
+	var steps []StepSnapshot[testPoint]
+	for snapshot, err := range reference.Steps() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		steps = append(steps, snapshot)
+	}
+	firstStep := func(matches func(previous, snapshot StepSnapshot[testPoint]) bool) int {
+		for i := 1; i < len(steps); i++ {
+			if matches(steps[i-1], steps[i]) {
+				return steps[i].StepIndex
+			}
+		}
+		t.Fatal("no step matches")
+		return 0
+	}
+	expandedAt := func(i int) testPoint { return steps[i].Current }
+	opened := expandedAt(len(steps) / 2)
+	// The shortest path costs more than h(start), so later expansions have
+	// a higher f.
+	limit := manhattan(start, goal)
+
+	tests := []struct {
+		name       string
+		breakpoint Breakpoint[testPoint]
+		condition  Breakpoint[testPoint]
+		want       int
+	}{
+		{
+			name:       "opened",
+			breakpoint: BreakOnOpened(opened),
+			want: firstStep(func(previous, snapshot StepSnapshot[testPoint]) bool {
+				return snapshot.Open[opened] && !previous.Open[opened]
+			}),
+		},
+		{
+			name:       "expanded",
+			breakpoint: BreakOnExpanded(expandedAt(30)),
+			want:       steps[30].StepIndex,
+		},
+		{
+			name:       "f above",
+			breakpoint: BreakOnFAbove[testPoint](limit),
+			want: firstStep(func(previous, snapshot StepSnapshot[testPoint]) bool {
+				return len(snapshot.Closed) > len(previous.Closed) && snapshot.Scores[snapshot.Current].FCost > limit
+			}),
+		},
+		{
+			name:      "condition",
+			condition: func(snapshot StepSnapshot[testPoint]) bool { return snapshot.Current == expandedAt(40) },
+			want:      steps[40].StepIndex,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stepper := newStepper()
+			defer stepper.Close()
+			if test.breakpoint != nil {
+				stepper.AddBreakpoint(test.breakpoint)
+			}
+			intermediate := 0
+			snapshot, err := stepper.RunUntil(func(snapshot StepSnapshot[testPoint]) bool {
+				// Steps that do not stop must not copy the whole state.
+				if snapshot.Open != nil || snapshot.Closed != nil || snapshot.CameFrom != nil || snapshot.Scores != nil {
+					t.Fatalf("step %d: the condition saw a full snapshot", snapshot.StepIndex)
+				}
+				intermediate++
+				return test.condition != nil && test.condition(snapshot)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if snapshot.StepIndex != test.want || snapshot.Done {
+				t.Fatalf("stopped at step %d (done %v), want step %d", snapshot.StepIndex, snapshot.Done, test.want)
+			}
+			if intermediate == 0 || snapshot.Open == nil || snapshot.Closed == nil || snapshot.Scores == nil {
+				t.Fatalf("the returned snapshot is not complete after %d steps", intermediate)
+			}
+		})
+	}
+
+	t.Run("removed", func(t *testing.T) {
+		stepper := newStepper()
+		defer stepper.Close()
+		removed := stepper.AddBreakpoint(BreakOnExpanded(expandedAt(10)))
+		stepper.AddBreakpoint(BreakOnExpanded(expandedAt(20)))
+		stepper.RemoveBreakpoint(removed)
+		snapshot, err := stepper.RunUntil(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if snapshot.StepIndex != steps[20].StepIndex {
+			t.Fatalf("stopped at step %d, want step %d", snapshot.StepIndex, steps[20].StepIndex)
+		}
+	})
+}
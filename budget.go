@@ -0,0 +1,92 @@
+package astar
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// WithMaxExpansions stops the search with ErrBudgetExceeded once it has
+// expanded maxExpansions nodes without reaching the goal.
+func WithMaxExpansions(maxExpansions int) Option {
+	return func(options *Options) { options.MaxExpansions = maxExpansions }
+}
+
+// WithMaxCost stops the search with ErrBudgetExceeded when the lowest f-cost
+// in the open set exceeds maxCost. With an admissible heuristic this means no
+// path of cost maxCost or less exists.
+func WithMaxCost(maxCost float64) Option {
+	return func(options *Options) { options.MaxCost = maxCost }
+}
+
+// WithDeadline stops the search with ErrBudgetExceeded at deadline. Unlike a
+// context deadline, running out of time still yields a partial Result.
+func WithDeadline(deadline time.Time) Option {
+	return func(options *Options) { options.Deadline = deadline }
+}
+
+// budget holds the limits set by WithMaxExpansions, WithMaxCost and
+// WithDeadline.
+type budget struct {
+	maxExpansions int
+	maxCost       float64
+	deadline      time.Time
+}
+
+func newBudget(searchOptions Options) budget {
+	maxCost := searchOptions.MaxCost
+	if math.IsNaN(maxCost) {
+		maxCost = math.Inf(1)
+	}
+	return budget{
+		maxExpansions: searchOptions.MaxExpansions,
+		maxCost:       maxCost,
+		deadline:      searchOptions.Deadline,
+	}
+}
+
+// checkBefore reports whether the expansion or time budget is spent.
+func (b budget) checkBefore(expandedNodes int) error {
+	if b.maxExpansions > 0 && expandedNodes >= b.maxExpansions {
+		return fmt.Errorf("%w: %d expansions", ErrBudgetExceeded, expandedNodes)
+	}
+	if !b.deadline.IsZero() && !time.Now().Before(b.deadline) {
+		return fmt.Errorf("%w: deadline reached", ErrBudgetExceeded)
+	}
+	return nil
+}
+
+// checkCost reports whether the best open f-cost is above the cost budget.
+func (b budget) checkCost(fCost float64) error {
+	if fCost > b.maxCost {
+		return fmt.Errorf("%w: f-cost %v above %v", ErrBudgetExceeded, fCost, b.maxCost)
+	}
+	return nil
+}
+
+// partialResult describes the best effort of a search stopped by its
+// budget: the path to the open node with the lowest heuristic estimate,
+// i.e. the one that appears closest to the goal.
+func (o *orchestrator[NodeType]) partialResult() Result[NodeType] {
+	var best *PriorityQueueItem[NodeType]
+	for item := range o.openSet.All() {
+		if best == nil {
+			best = item
+			continue
+		}
+		h, bestH := item.FCost-item.GScore, best.FCost-best.GScore
+		if h < bestH || (h == bestH && item.FCost < best.FCost) {
+			best = item
+		}
+	}
+	result := Result[NodeType]{
+		Path:          []NodeType{o.startNode},
+		ExpandedNodes: o.expandedNodes,
+		Partial:       true,
+	}
+	if best != nil {
+		result.Path = o.path(best.Node)
+		result.TotalCost = best.GScore
+	}
+	return result
+}
@@ -0,0 +1,77 @@
+package astar
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// slowGrid takes a millisecond per Neighbors call.
+type slowGrid struct{ testGrid }
+
+func (grid slowGrid) Neighbors(node testPoint) []Neighbor[testPoint] {
+	time.Sleep(time.Millisecond)
+	return grid.testGrid.Neighbors(node)
+}
+
+// TestBudgetPartialResult stops searches with each budget and checks that
+// the partial path ends at the open node with the lowest h, rebuilt from a
+// trace of the search, and that its cost matches.
+func TestBudgetPartialResult(t *testing.T) {
+	grid := newTestGrid(30, 30, 0.3, 4)
+	start, goal := testPoint{0, 0}, testPoint{29, 29}
+	// The shortest path costs 64, 6 more than the Manhattan distance.
+	tests := []struct {
+		name   string
+		graph  Graph[testPoint]
+		option func() Option
+	}{
+		{"max expansions", grid, func() Option { return WithMaxExpansions(40) }},
+		{"max cost", grid, func() Option { return WithMaxCost(60) }},
+		{"deadline", slowGrid{grid}, func() Option { return WithDeadline(time.Now().Add(50 * time.Millisecond)) }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var written bytes.Buffer
+			result, err := Search(context.Background(), test.graph, start, goal, manhattan, test.option(),
+				WithWorkers(2), WithTracer[testPoint](NewTraceWriter(&written, start, goal)))
+			if !errors.Is(err, ErrBudgetExceeded) || !result.Partial || result.Found {
+				t.Fatalf("got %v, Partial %v, Found %v; want ErrBudgetExceeded and a partial result", err, result.Partial, result.Found)
+			}
+			trace, err := ReadTrace[testPoint](&written)
+			if err != nil {
+				t.Fatal(err)
+			}
+			replay := NewTraceReplay(trace)
+			for _, err := range replay.Steps() {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			snapshot := replay.Snapshot()
+			var best NodeScore
+			found := false
+			for node := range snapshot.Open {
+				score := snapshot.Scores[node]
+				if !found || score.HScore < best.HScore || score.HScore == best.HScore && score.FCost < best.FCost {
+					best, found = score, true
+				}
+			}
+			if !found || len(result.Path) < 2 || result.Path[0] != start {
+				t.Fatalf("path %v with open set %v", result.Path, snapshot.Open)
+			}
+			end := result.Path[len(result.Path)-1]
+			if !snapshot.Open[end] {
+				t.Fatalf("path ends at %v, which is not open", end)
+			}
+			if score := snapshot.Scores[end]; score.HScore != best.HScore || score.FCost != best.FCost {
+				t.Fatalf("path ends at a node with h=%g f=%g, want h=%g f=%g", score.HScore, score.FCost, best.HScore, best.FCost)
+			}
+			if want := float64(len(result.Path) - 1); result.TotalCost != want || result.TotalCost != snapshot.Scores[end].GScore {
+				t.Fatalf("TotalCost %g, want %g", result.TotalCost, want)
+			}
+		})
+	}
+}
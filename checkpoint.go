@@ -0,0 +1,488 @@
+package astar
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"time"
+)
+
+// CheckpointVersion is the version of the binary format written by
+// WriteCheckpoint. It changes whenever the layout does.
+//
+//   - 1: first format.
+//   - 2: adds Current.
+//   - 3: adds Blocked.
+const CheckpointVersion = 3
+
+// oldestCheckpointVersion is the oldest version ReadCheckpoint and
+// RestoreStepper accept. Fields missing from older versions are left zero.
+const oldestCheckpointVersion = 1
+
+// checkpointMagic starts every binary checkpoint.
+const checkpointMagic = "astarckp"
+
+// maxCheckpointNodeSize bounds the encoded size of a single node so that a
+// corrupt length cannot make ReadCheckpoint allocate without limit.
+const maxCheckpointNodeSize = 1 << 24
+
+// StepperCheckpoint is the complete state of a Stepper. It contains only
+// exported fields, so it can be stored with encoding/gob when the node type
+// supports it, or with WriteCheckpoint and a NodeCodec.
+type StepperCheckpoint[NodeType comparable] struct {
+	Version int
+	Start   NodeType
+	Goal    NodeType
+	// Current is the node expanded by the last step.
+	Current   NodeType
+	StepIndex int
+	// ExpandedNodes and NextSequence restore the counters of the search.
+	ExpandedNodes int
+	NextSequence  uint64
+	LastPoppedF   float64
+	Started       bool
+	Done          bool
+	Found         bool
+	Stats         Statistics
+	// Open lists the open set in the internal order of the open list, which
+	// lets a restored stepper pop nodes in exactly the same order.
+	Open     []CheckpointItem[NodeType]
+	Closed   []NodeType
+	GScores  map[NodeType]float64
+	CameFrom map[NodeType]NodeType
+	// Blocked lists the obstacles added with Stepper.AddObstacle.
+	Blocked []NodeType
+}
+
+// CheckpointItem is an entry of the open set in a checkpoint.
+type CheckpointItem[NodeType comparable] struct {
+	Node     NodeType
+	GScore   float64
+	FCost    float64
+	Sequence uint64
+}
+
+// NodeCodec converts nodes to bytes and back for WriteCheckpoint and
+// ReadCheckpoint.
+type NodeCodec[NodeType comparable] interface {
+	// AppendNode appends the encoding of node to buffer.
+	AppendNode(buffer []byte, node NodeType) ([]byte, error)
+	// DecodeNode decodes a node appended by AppendNode.
+	DecodeNode(data []byte) (NodeType, error)
+}
+
+// Checkpoint captures the state of the stepper. The stepper can keep
+// stepping afterwards; the checkpoint does not share memory with it.
+func (s *Stepper[NodeType]) Checkpoint() *StepperCheckpoint[NodeType] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o := s.orchestrator
+	checkpoint := &StepperCheckpoint[NodeType]{
+		Version:       CheckpointVersion,
+		Start:         o.startNode,
+		Goal:          o.goalNode,
+		Current:       s.current,
+		StepIndex:     s.stepCount,
+		ExpandedNodes: o.expandedNodes,
+		NextSequence:  o.nextSequence,
+		LastPoppedF:   o.lastPoppedF,
+		Started:       s.started,
+		Done:          s.done,
+		Found:         s.found,
+		Stats:         s.statistics(),
+		Open:          make([]CheckpointItem[NodeType], 0, o.openSet.Len()),
+		Closed:        make([]NodeType, 0, len(s.state.closedSet)),
+		GScores:       make(map[NodeType]float64, len(s.state.gScores)),
+		CameFrom:      copyCameFrom(s.state.cameFrom),
+	}
+	for item := range o.openSet.All() {
+		checkpoint.Open = append(checkpoint.Open, CheckpointItem[NodeType]{
+			Node:     item.Node,
+			GScore:   item.GScore,
+			FCost:    item.FCost,
+			Sequence: item.sequence,
+		})
+	}
+	for node := range s.state.closedSet {
+		checkpoint.Closed = append(checkpoint.Closed, node)
+	}
+	for node, gScore := range s.state.gScores {
+		checkpoint.GScores[node] = gScore
+	}
+	if checkpoint.CameFrom == nil {
+		checkpoint.CameFrom = map[NodeType]NodeType{}
+	}
+	for node := range o.blocked {
+		checkpoint.Blocked = append(checkpoint.Blocked, node)
+	}
+	return checkpoint
+}
+
+// RestoreStepper creates a stepper that continues the search captured by
+// checkpoint. The graph and heuristic must be the ones of the original
+// search; the options may differ, except that changing the tie-breaking
+// policy changes which nodes are expanded next.
+func RestoreStepper[NodeType comparable](
+	parent context.Context,
+	graph Graph[NodeType],
+	heuristic Heuristic[NodeType],
+	checkpoint *StepperCheckpoint[NodeType],
+	options ...Option,
+) (*Stepper[NodeType], error) {
+	if err := checkCheckpointVersion(checkpoint.Version); err != nil {
+		return nil, err
+	}
+	opts := applyOptions(defaultOptions(), options)
+	pool := newWorkerPool[NodeType](opts.NumberOfWorkers)
+	s := newStepper(parent, graph, pool, checkpoint.Start, checkpoint.Goal, heuristic, opts)
+	s.ownedPool = pool
+	runtime.AddCleanup(s, (*workerPool[NodeType]).close, pool)
+	if err := s.restore(checkpoint); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// restore loads checkpoint into a stepper that has not started.
+func (s *Stepper[NodeType]) restore(checkpoint *StepperCheckpoint[NodeType]) error {
+	o := s.orchestrator
+	for node, gScore := range checkpoint.GScores {
+		s.state.setGScore(node, gScore)
+	}
+	if err := s.restoreScores(checkpoint); err != nil {
+		return err
+	}
+	for node, parent := range checkpoint.CameFrom {
+		s.state.setParent(node, parent)
+	}
+	for _, node := range checkpoint.Closed {
+		s.state.setClosed(node)
+	}
+	if len(checkpoint.Blocked) > 0 {
+		o.blocked = make(map[NodeType]bool, len(checkpoint.Blocked))
+		for _, node := range checkpoint.Blocked {
+			o.blocked[node] = true
+		}
+	}
+	// Pushing the items in the order All yielded them rebuilds the same
+	// open list layout.
+	for _, entry := range checkpoint.Open {
+		if _, inOpen := s.state.openItem(entry.Node); inOpen {
+			return fmt.Errorf("%w: node %v is open twice", ErrInvalidCheckpoint, entry.Node)
+		}
+		item := &PriorityQueueItem[NodeType]{
+			Node:     entry.Node,
+			GScore:   entry.GScore,
+			FCost:    entry.FCost,
+			sequence: entry.Sequence,
+		}
+		o.openSet.Push(item)
+		s.state.setOpenItem(item.Node, item)
+	}
+	o.expandedNodes = checkpoint.ExpandedNodes
+	o.nextSequence = checkpoint.NextSequence
+	o.lastPoppedF = checkpoint.LastPoppedF
+	o.stats = checkpoint.Stats
+	o.stats.WallTime = 0
+	s.elapsed = checkpoint.Stats.WallTime
+	s.current = checkpoint.Current
+	s.stepCount = checkpoint.StepIndex
+	s.started = checkpoint.Started
+	s.done = checkpoint.Done
+	s.found = checkpoint.Found
+	if s.started && !s.done {
+		o.resumeReporting()
+	}
+	if s.done {
+		s.close()
+	}
+	return nil
+}
+
+// restoreScores rebuilds the node scores shown in snapshots. They are not
+// stored in checkpoints: open nodes keep their f-cost, the heuristic is
+// evaluated again for the others.
+func (s *Stepper[NodeType]) restoreScores(checkpoint *StepperCheckpoint[NodeType]) error {
+	for _, entry := range checkpoint.Open {
+		s.recorder.scores[entry.Node] = NodeScore{GScore: entry.GScore, HScore: entry.FCost - entry.GScore, FCost: entry.FCost}
+	}
+	for node, gScore := range checkpoint.GScores {
+		if _, known := s.recorder.scores[node]; known {
+			continue
+		}
+		h, err := safeHeuristic(s.orchestrator.heuristic, node, checkpoint.Goal)
+		if err != nil {
+			return err
+		}
+		s.recorder.scores[node] = NodeScore{GScore: gScore, HScore: h, FCost: gScore + h}
+	}
+	return nil
+}
+
+// resumeReporting restarts the clocks of the logger and the progress
+// reports of a restored search.
+func (o *orchestrator[NodeType]) resumeReporting() {
+	now := time.Now()
+	if o.logger != nil {
+		o.logger.started, o.logger.lastLog = now, now
+	}
+	if o.progress != nil {
+		h, err := safeHeuristic(o.heuristic, o.startNode, o.goalNode)
+		if err != nil {
+			h = 0
+		}
+		o.progress.start(h)
+	}
+}
+
+// WriteCheckpoint writes checkpoint to w in a versioned binary format, using
+// codec for the nodes.
+func WriteCheckpoint[NodeType comparable](w io.Writer, checkpoint *StepperCheckpoint[NodeType], codec NodeCodec[NodeType]) error {
+	e := &checkpointEncoder[NodeType]{writer: bufio.NewWriter(w), codec: codec}
+	e.bytes([]byte(checkpointMagic))
+	e.uvarint(CheckpointVersion)
+	e.node(checkpoint.Start)
+	e.node(checkpoint.Goal)
+	e.node(checkpoint.Current)
+	e.uvarint(uint64(checkpoint.StepIndex))
+	e.uvarint(uint64(checkpoint.ExpandedNodes))
+	e.uvarint(checkpoint.NextSequence)
+	e.float(checkpoint.LastPoppedF)
+	e.flag(checkpoint.Started)
+	e.flag(checkpoint.Done)
+	e.flag(checkpoint.Found)
+	stats := checkpoint.Stats
+	for _, counter := range []int{stats.GeneratedNodes, stats.Relaxations, stats.DecreaseKeys, stats.MaxFrontierSize, stats.Reopenings} {
+		e.uvarint(uint64(counter))
+	}
+	for _, duration := range []time.Duration{stats.WallTime, stats.OrchestratorTime, stats.WorkerTime, stats.QueueWait} {
+		e.uvarint(uint64(duration))
+	}
+	e.uvarint(uint64(len(checkpoint.Open)))
+	for _, entry := range checkpoint.Open {
+		e.node(entry.Node)
+		e.float(entry.GScore)
+		e.float(entry.FCost)
+		e.uvarint(entry.Sequence)
+	}
+	e.uvarint(uint64(len(checkpoint.Closed)))
+	for _, node := range checkpoint.Closed {
+		e.node(node)
+	}
+	e.uvarint(uint64(len(checkpoint.GScores)))
+	for node, gScore := range checkpoint.GScores {
+		e.node(node)
+		e.float(gScore)
+	}
+	e.uvarint(uint64(len(checkpoint.CameFrom)))
+	for node, parent := range checkpoint.CameFrom {
+		e.node(node)
+		e.node(parent)
+	}
+	e.uvarint(uint64(len(checkpoint.Blocked)))
+	for _, node := range checkpoint.Blocked {
+		e.node(node)
+	}
+	if e.err != nil {
+		return e.err
+	}
+	return e.writer.Flush()
+}
+
+// ReadCheckpoint reads a checkpoint written by WriteCheckpoint.
+func ReadCheckpoint[NodeType comparable](r io.Reader, codec NodeCodec[NodeType]) (*StepperCheckpoint[NodeType], error) {
+	d := &checkpointDecoder[NodeType]{reader: bufio.NewReader(r), codec: codec}
+	if magic := d.bytes(len(checkpointMagic)); d.err == nil && string(magic) != checkpointMagic {
+		return nil, fmt.Errorf("%w: bad magic %q", ErrInvalidCheckpoint, magic)
+	}
+	checkpoint := &StepperCheckpoint[NodeType]{Version: int(d.uvarint())}
+	if d.err == nil {
+		if err := checkCheckpointVersion(checkpoint.Version); err != nil {
+			return nil, err
+		}
+	}
+	checkpoint.Start = d.node()
+	checkpoint.Goal = d.node()
+	if checkpoint.Version >= 2 {
+		checkpoint.Current = d.node()
+	}
+	checkpoint.StepIndex = int(d.uvarint())
+	checkpoint.ExpandedNodes = int(d.uvarint())
+	checkpoint.NextSequence = d.uvarint()
+	checkpoint.LastPoppedF = d.float()
+	checkpoint.Started = d.flag()
+	checkpoint.Done = d.flag()
+	checkpoint.Found = d.flag()
+	stats := &checkpoint.Stats
+	for _, counter := range []*int{&stats.GeneratedNodes, &stats.Relaxations, &stats.DecreaseKeys, &stats.MaxFrontierSize, &stats.Reopenings} {
+		*counter = int(d.uvarint())
+	}
+	for _, duration := range []*time.Duration{&stats.WallTime, &stats.OrchestratorTime, &stats.WorkerTime, &stats.QueueWait} {
+		*duration = time.Duration(d.uvarint())
+	}
+	for n := d.count(); n > 0 && d.err == nil; n-- {
+		checkpoint.Open = append(checkpoint.Open, CheckpointItem[NodeType]{
+			Node:     d.node(),
+			GScore:   d.float(),
+			FCost:    d.float(),
+			Sequence: d.uvarint(),
+		})
+	}
+	for n := d.count(); n > 0 && d.err == nil; n-- {
+		checkpoint.Closed = append(checkpoint.Closed, d.node())
+	}
+	checkpoint.GScores = map[NodeType]float64{}
+	for n := d.count(); n > 0 && d.err == nil; n-- {
+		node := d.node()
+		checkpoint.GScores[node] = d.float()
+	}
+	checkpoint.CameFrom = map[NodeType]NodeType{}
+	for n := d.count(); n > 0 && d.err == nil; n-- {
+		node := d.node()
+		checkpoint.CameFrom[node] = d.node()
+	}
+	if checkpoint.Version >= 3 {
+		for n := d.count(); n > 0 && d.err == nil; n-- {
+			checkpoint.Blocked = append(checkpoint.Blocked, d.node())
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return checkpoint, nil
+}
+
+// checkCheckpointVersion rejects the versions this package cannot read.
+func checkCheckpointVersion(version int) error {
+	if version < oldestCheckpointVersion || version > CheckpointVersion {
+		return fmt.Errorf("%w: version %d, want %d to %d", ErrInvalidCheckpoint, version, oldestCheckpointVersion, CheckpointVersion)
+	}
+	return nil
+}
+
+// checkpointEncoder writes checkpoint fields and keeps the first error.
+type checkpointEncoder[NodeType comparable] struct {
+	writer  *bufio.Writer
+	codec   NodeCodec[NodeType]
+	scratch []byte
+	err     error
+}
+
+func (e *checkpointEncoder[NodeType]) bytes(data []byte) {
+	if e.err == nil {
+		_, e.err = e.writer.Write(data)
+	}
+}
+
+func (e *checkpointEncoder[NodeType]) uvarint(value uint64) {
+	e.scratch = binary.AppendUvarint(e.scratch[:0], value)
+	e.bytes(e.scratch)
+}
+
+func (e *checkpointEncoder[NodeType]) float(value float64) {
+	e.scratch = binary.LittleEndian.AppendUint64(e.scratch[:0], math.Float64bits(value))
+	e.bytes(e.scratch)
+}
+
+func (e *checkpointEncoder[NodeType]) flag(value bool) {
+	if value {
+		e.uvarint(1)
+	} else {
+		e.uvarint(0)
+	}
+}
+
+func (e *checkpointEncoder[NodeType]) node(node NodeType) {
+	if e.err != nil {
+		return
+	}
+	encoded, err := e.codec.AppendNode(nil, node)
+	if err != nil {
+		e.err = fmt.Errorf("encode node %v: %w", node, err)
+		return
+	}
+	e.uvarint(uint64(len(encoded)))
+	e.bytes(encoded)
+}
+
+// checkpointDecoder reads checkpoint fields and keeps the first error. Once
+// it failed, every read returns a zero value.
+type checkpointDecoder[NodeType comparable] struct {
+	reader *bufio.Reader
+	codec  NodeCodec[NodeType]
+	err    error
+}
+
+func (d *checkpointDecoder[NodeType]) fail(err error) {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	d.err = fmt.Errorf("%w: %w", ErrInvalidCheckpoint, err)
+}
+
+func (d *checkpointDecoder[NodeType]) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(d.reader, data); err != nil {
+		d.fail(err)
+		return nil
+	}
+	return data
+}
+
+func (d *checkpointDecoder[NodeType]) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	value, err := binary.ReadUvarint(d.reader)
+	if err != nil {
+		d.fail(err)
+	}
+	return value
+}
+
+// count reads the length of a section.
+func (d *checkpointDecoder[NodeType]) count() int {
+	n := d.uvarint()
+	if n > math.MaxInt32 {
+		d.fail(fmt.Errorf("section of %d entries", n))
+		return 0
+	}
+	return int(n)
+}
+
+func (d *checkpointDecoder[NodeType]) float() float64 {
+	data := d.bytes(8)
+	if data == nil {
+		return 0
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(data))
+}
+
+func (d *checkpointDecoder[NodeType]) flag() bool {
+	return d.uvarint() != 0
+}
+
+func (d *checkpointDecoder[NodeType]) node() NodeType {
+	var node NodeType
+	size := d.uvarint()
+	if size > maxCheckpointNodeSize {
+		d.fail(fmt.Errorf("node of %d bytes", size))
+	}
+	data := d.bytes(int(size))
+	if d.err != nil {
+		return node
+	}
+	node, err := d.codec.DecodeNode(data)
+	if err != nil {
+		d.err = fmt.Errorf("%w: decode node: %w", ErrInvalidCheckpoint, err)
+	}
+	return node
+}
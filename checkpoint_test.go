@@ -0,0 +1,177 @@
+package astar
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// pointCodec encodes a testPoint as two fixed-size integers.
+type pointCodec struct{}
+
+func (pointCodec) AppendNode(buffer []byte, node testPoint) ([]byte, error) {
+	buffer = binary.LittleEndian.AppendUint64(buffer, uint64(node[0]))
+	return binary.LittleEndian.AppendUint64(buffer, uint64(node[1])), nil
+}
+
+func (pointCodec) DecodeNode(data []byte) (testPoint, error) {
+	if len(data) != 16 {
+		return testPoint{}, errors.New("bad point")
+	}
+	return testPoint{int(binary.LittleEndian.Uint64(data)), int(binary.LittleEndian.Uint64(data[8:]))}, nil
+}
+
+// remainingSteps runs stepper to the end and returns the expanded nodes
+// and the final path.
+func remainingSteps(t *testing.T, stepper *Stepper[testPoint]) ([]testPoint, []testPoint) {
+	t.Helper()
+	var expanded, path []testPoint
+	for snapshot, err := range stepper.Steps() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		expanded = append(expanded, snapshot.Current)
+		path = snapshot.Path
+	}
+	return expanded, path
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	grid := newTestGrid(30, 30, 0.25, 2)
+	goal := testPoint{29, 29}
+	for _, test := range openListKinds {
+		t.Run(test.name, func(t *testing.T) {
+			options := []Option{WithWorkers(2), WithDeterministic(), WithOpenList(test.kind)}
+			original := NewStepper(context.Background(), grid, testPoint{0, 0}, goal, manhattan, options...)
+			if _, err := original.StepN(60); err != nil {
+				t.Fatal(err)
+			}
+			checkpoint := original.Checkpoint()
+			var buffer bytes.Buffer
+			if err := WriteCheckpoint(&buffer, checkpoint, pointCodec{}); err != nil {
+				t.Fatal(err)
+			}
+			read, err := ReadCheckpoint[testPoint](&buffer, pointCodec{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(read, checkpoint) {
+				t.Fatalf("read checkpoint differs:\n got %+v\nwant %+v", read, checkpoint)
+			}
+			restored, err := RestoreStepper(context.Background(), grid, manhattan, read, options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := restored.Snapshot(); got.Current != checkpoint.Current || got.StepIndex != 60 {
+				t.Fatalf("restored at %v step %d, want %v step 60", got.Current, got.StepIndex, checkpoint.Current)
+			}
+			wantExpanded, wantPath := remainingSteps(t, original)
+			gotExpanded, gotPath := remainingSteps(t, restored)
+			if !reflect.DeepEqual(gotExpanded, wantExpanded) || !reflect.DeepEqual(gotPath, wantPath) {
+				t.Fatalf("restored search diverged:\n got %v\nwant %v", gotExpanded, wantExpanded)
+			}
+		})
+	}
+}
+
+// TestReadCheckpointVersion1 reads a file without the Current field added
+// in version 2 and the Blocked section added in version 3.
+func TestReadCheckpointVersion1(t *testing.T) {
+	stepper := NewStepper(context.Background(), newTestGrid(10, 10, 0, 0), testPoint{0, 0}, testPoint{9, 9}, manhattan, WithWorkers(1))
+	defer stepper.Close()
+	stepper.StepN(5)
+	checkpoint := stepper.Checkpoint()
+	var buffer bytes.Buffer
+	if err := WriteCheckpoint(&buffer, checkpoint, pointCodec{}); err != nil {
+		t.Fatal(err)
+	}
+	// Magic, version, then start, goal and current as a length and 16 bytes.
+	data := buffer.Bytes()
+	header := len(checkpointMagic)
+	version1 := append([]byte{}, data[:header]...)
+	version1 = append(version1, 1)
+	version1 = append(version1, data[header+1:header+1+2*17]...)
+	// The empty Blocked section is the last byte.
+	version1 = append(version1, data[header+1+3*17:len(data)-1]...)
+
+	read, err := ReadCheckpoint[testPoint](bytes.NewReader(version1), pointCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := *checkpoint
+	want.Version, want.Current = 1, testPoint{}
+	if !reflect.DeepEqual(*read, want) {
+		t.Fatalf("got %+v\nwant %+v", *read, want)
+	}
+	restored, err := RestoreStepper(context.Background(), newTestGrid(10, 10, 0, 0), manhattan, read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored.Close()
+}
+
+// TestCheckpointKeepsObstacles restores a stepper after AddObstacle and
+// checks that the restored search still avoids the obstacle.
+func TestCheckpointKeepsObstacles(t *testing.T) {
+	grid := newTestGrid(20, 20, 0, 0)
+	start, goal := testPoint{0, 0}, testPoint{19, 19}
+	options := []Option{WithWorkers(2), WithDeterministic()}
+	original := NewStepper(context.Background(), grid, start, goal, manhattan, options...)
+	// Wall off the goal, so that only a search that forgets an obstacle
+	// finds a path.
+	for _, node := range []testPoint{{18, 19}, {19, 18}} {
+		if err := original.AddObstacle(node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	original.StepN(10)
+	var buffer bytes.Buffer
+	if err := WriteCheckpoint(&buffer, original.Checkpoint(), pointCodec{}); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadCheckpoint[testPoint](&buffer, pointCodec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read.Blocked) != 2 {
+		t.Fatalf("Blocked = %v, want the two obstacles", read.Blocked)
+	}
+	restored, err := RestoreStepper(context.Background(), grid, manhattan, read, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, stepper := range map[string]*Stepper[testPoint]{"original": original, "restored": restored} {
+		snapshot, err := stepper.RunUntil(func(StepSnapshot[testPoint]) bool { return false })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !snapshot.Done || snapshot.Found {
+			t.Fatalf("%s search: done %v found %v, want no path", name, snapshot.Done, snapshot.Found)
+		}
+	}
+}
+
+func TestReadCheckpointRejectsBadInput(t *testing.T) {
+	stepper := NewStepper(context.Background(), newTestGrid(10, 10, 0, 0), testPoint{0, 0}, testPoint{9, 9}, manhattan, WithWorkers(1))
+	defer stepper.Close()
+	stepper.StepN(5)
+	var buffer bytes.Buffer
+	if err := WriteCheckpoint(&buffer, stepper.Checkpoint(), pointCodec{}); err != nil {
+		t.Fatal(err)
+	}
+	data := buffer.Bytes()
+	future := append([]byte{}, data...)
+	future[len(checkpointMagic)] = CheckpointVersion + 1
+	for name, input := range map[string][]byte{
+		"truncated":      data[:len(data)/2],
+		"future version": future,
+		"bad magic":      append([]byte("notackpt"), data[len(checkpointMagic):]...),
+	} {
+		if _, err := ReadCheckpoint[testPoint](bytes.NewReader(input), pointCodec{}); !errors.Is(err, ErrInvalidCheckpoint) {
+			t.Errorf("%s: err = %v, want ErrInvalidCheckpoint", name, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package astar
+
+import (
+	"context"
+	"fmt"
+)
+
+// GraphE is a graph whose neighbor lookup can fail or block, such as a graph
+// backed by a database or a tile cache. Wrap it with ContextGraph to search
+// it: the search passes its context to Neighbors and stops on the first
+// error, returning it wrapped in a *NodeError.
+type GraphE[NodeType comparable] interface {
+	Neighbors(contextObject context.Context, node NodeType) ([]Neighbor[NodeType], error)
+}
+
+// NodeError reports an error returned by GraphE.Neighbors together with the
+// node that was being expanded.
+type NodeError[NodeType comparable] struct {
+	Node NodeType
+	Err  error
+}
+
+func (e *NodeError[NodeType]) Error() string {
+	return fmt.Sprintf("neighbors of node %v: %v", e.Node, e.Err)
+}
+
+func (e *NodeError[NodeType]) Unwrap() error { return e.Err }
+
+// ContextGraph adapts a GraphE so it can be passed to Search, Start,
+// NewStepper and Engine. Those recognize the adapter and call the
+// context-aware method directly. If graph also implements Indexer, an Engine
+// keeps the search state in slices as it does for an IndexedGraph; the
+// adapter cannot be passed to SearchIndexed.
+//
+// The adapter's own Neighbors method, used only when it is called outside a
+// search, runs with context.Background and panics on error.
+func ContextGraph[NodeType comparable](graph GraphE[NodeType]) Graph[NodeType] {
+	return contextGraph[NodeType]{graph: graph}
+}
+
+type contextGraph[NodeType comparable] struct {
+	graph GraphE[NodeType]
+}
+
+func (g contextGraph[NodeType]) Neighbors(node NodeType) []Neighbor[NodeType] {
+	neighbors, err := g.graph.Neighbors(context.Background(), node)
+	if err != nil {
+		panic(&NodeError[NodeType]{Node: node, Err: err})
+	}
+	return neighbors
+}
+
+// indexerOf returns the Indexer implemented by graph or by the GraphE it
+// wraps.
+func indexerOf[NodeType comparable](graph Graph[NodeType]) (Indexer[NodeType], bool) {
+	if adapter, isAdapter := graph.(contextGraph[NodeType]); isAdapter {
+		indexer, isIndexed := adapter.graph.(Indexer[NodeType])
+		return indexer, isIndexed
+	}
+	indexer, isIndexed := graph.(Indexer[NodeType])
+	return indexer, isIndexed
+}
+
+// neighborsOf fetches the neighbors of node, using the context-aware method
+// when graph wraps a GraphE. Errors come back as *NodeError and panics as
+// *PanicError.
+func neighborsOf[NodeType comparable](
+	contextObject context.Context,
+	graph Graph[NodeType],
+	node NodeType,
+) (neighbors []Neighbor[NodeType], err error) {
+	defer recoverPanic(node, "Neighbors", &err)
+	if adapter, isAdapter := graph.(contextGraph[NodeType]); isAdapter {
+		neighbors, err = adapter.graph.Neighbors(contextObject, node)
+		if err != nil {
+			return nil, &NodeError[NodeType]{Node: node, Err: err}
+		}
+		return neighbors, nil
+	}
+	return graph.Neighbors(node), nil
+}
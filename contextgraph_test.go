@@ -0,0 +1,66 @@
+package astar
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type contextKey struct{}
+
+var errTileMissing = errors.New("tile missing")
+
+// failingGraph is a GraphE over a testGrid that fails on one node and
+// records whether the caller's context reached it.
+type failingGraph struct {
+	testGrid
+	failAt      testPoint
+	sawCaller   bool
+	wrongCaller bool
+}
+
+func (graph *failingGraph) Neighbors(contextObject context.Context, node testPoint) ([]Neighbor[testPoint], error) {
+	if contextObject.Value(contextKey{}) == "caller" {
+		graph.sawCaller = true
+	} else {
+		graph.wrongCaller = true
+	}
+	if node == graph.failAt {
+		return nil, errTileMissing
+	}
+	return graph.testGrid.Neighbors(node), nil
+}
+
+// TestContextGraphError checks that a GraphE error stops Search and a
+// Stepper with a *NodeError, and that the caller's context reaches the
+// GraphE.
+func TestContextGraphError(t *testing.T) {
+	contextObject := context.WithValue(context.Background(), contextKey{}, "caller")
+	check := func(name string, graph *failingGraph, err error) {
+		t.Helper()
+		var nodeError *NodeError[testPoint]
+		if !errors.As(err, &nodeError) || nodeError.Node != graph.failAt || !errors.Is(err, errTileMissing) {
+			t.Fatalf("%s: error %v, want a *NodeError for %v", name, err, graph.failAt)
+		}
+		if !graph.sawCaller || graph.wrongCaller {
+			t.Fatalf("%s: the caller's context did not reach Neighbors", name)
+		}
+	}
+
+	graph := &failingGraph{testGrid: newTestGrid(10, 10, 0, 1), failAt: testPoint{2, 0}}
+	_, err := Search(contextObject, ContextGraph[testPoint](graph), testPoint{0, 0}, testPoint{9, 0}, manhattan)
+	check("Search", graph, err)
+
+	graph = &failingGraph{testGrid: newTestGrid(10, 10, 0, 1), failAt: testPoint{2, 0}}
+	stepper := NewStepper(contextObject, ContextGraph[testPoint](graph), testPoint{0, 0}, testPoint{9, 0}, manhattan)
+	for {
+		snapshot, err := stepper.Step()
+		if err != nil {
+			check("Stepper", graph, err)
+			break
+		}
+		if snapshot.Done {
+			t.Fatal("Stepper: search finished without the error")
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package astar
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+)
+
+// TestDeterministicRepeats runs the same query many times with several
+// workers and checks that the path, the expansion count and the trace never
+// change.
+func TestDeterministicRepeats(t *testing.T) {
+	grid := newTestGrid(40, 40, 0.25, 2)
+	start, goal := testPoint{0, 0}, testPoint{39, 39}
+	var first Result[testPoint]
+	var firstTrace *Trace[testPoint]
+	for run := range 20 {
+		var written bytes.Buffer
+		result, err := Search(context.Background(), grid, start, goal, manhattan,
+			WithWorkers(8), WithDeterministic(), WithTracer[testPoint](NewTraceWriter(&written, start, goal)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		trace, err := ReadTrace[testPoint](&written)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if run == 0 {
+			first, firstTrace = result, trace
+			continue
+		}
+		if !reflect.DeepEqual(result.Path, first.Path) || result.ExpandedNodes != first.ExpandedNodes {
+			t.Fatalf("run %d: %d expansions and path %v, want %d and %v",
+				run, result.ExpandedNodes, result.Path, first.ExpandedNodes, first.Path)
+		}
+		if divergence := DiffTraces(firstTrace, trace); divergence != nil {
+			t.Fatalf("run %d: trace diverges at event %d (step %d)", run, divergence.Index, divergence.Step)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package astar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// WriteDOT writes the search tree of snapshot as a Graphviz DOT digraph.
+// Take the snapshot from Stepper.Snapshot or TraceReplay.Snapshot, the
+// latter also covering Search runs recorded with a TraceWriter. Edges go
+// from each node to the nodes it reached, following CameFrom, and are
+// labeled with their cost. Closed nodes are filled, open nodes are dashed
+// and the path, if any, is drawn in bold red. nodeLabel names the nodes;
+// nil uses fmt's %v. Nodes are written in label order, and nodes sharing a
+// label in the order of fmt's %#v, so the output is stable.
+func WriteDOT[NodeType comparable](writer io.Writer, snapshot StepSnapshot[NodeType], nodeLabel func(NodeType) string) error {
+	if nodeLabel == nil {
+		nodeLabel = func(node NodeType) string { return fmt.Sprint(node) }
+	}
+	labels := map[NodeType]string{}
+	addNode := func(node NodeType) {
+		if _, seen := labels[node]; !seen {
+			labels[node] = nodeLabel(node)
+		}
+	}
+	for node := range snapshot.Scores {
+		addNode(node)
+	}
+	for node := range snapshot.Closed {
+		addNode(node)
+	}
+	for node := range snapshot.Open {
+		addNode(node)
+	}
+	for node, parent := range snapshot.CameFrom {
+		addNode(node)
+		addNode(parent)
+	}
+	for _, node := range snapshot.Path {
+		addNode(node)
+	}
+	nodes := make([]NodeType, 0, len(labels))
+	for node := range labels {
+		nodes = append(nodes, node)
+	}
+	slices.SortFunc(nodes, func(a, b NodeType) int {
+		if order := strings.Compare(labels[a], labels[b]); order != 0 {
+			return order
+		}
+		return strings.Compare(fmt.Sprintf("%#v", a), fmt.Sprintf("%#v", b))
+	})
+	ids := make(map[NodeType]int, len(nodes))
+	for i, node := range nodes {
+		ids[node] = i
+	}
+	onPath := map[NodeType]bool{}
+	pathEdges := map[NodeType]bool{}
+	for i, node := range snapshot.Path {
+		onPath[node] = true
+		if i > 0 && snapshot.CameFrom[node] == snapshot.Path[i-1] {
+			pathEdges[node] = true
+		}
+	}
+
+	output := bufio.NewWriter(writer)
+	fmt.Fprintln(output, "digraph search {")
+	fmt.Fprintln(output, "\tnode [shape=ellipse, fontname=\"Helvetica\"];")
+	fmt.Fprintln(output, "\tedge [fontname=\"Helvetica\", fontsize=10];")
+	for _, node := range nodes {
+		label := labels[node]
+		if score, known := snapshot.Scores[node]; known {
+			label += fmt.Sprintf("\ng=%g h=%g f=%g", score.GScore, score.HScore, score.FCost)
+		}
+		attributes := "label=" + strconv.Quote(label)
+		switch {
+		case snapshot.Closed[node]:
+			attributes += ", style=filled, fillcolor=\"#d9d9d9\""
+		case snapshot.Open[node]:
+			attributes += ", style=dashed"
+			if !onPath[node] {
+				attributes += ", color=\"#1f77b4\""
+			}
+		}
+		if onPath[node] {
+			attributes += ", color=\"#d62728\", penwidth=2"
+		}
+		fmt.Fprintf(output, "\tn%d [%s];\n", ids[node], attributes)
+	}
+	for _, node := range nodes {
+		parent, hasParent := snapshot.CameFrom[node]
+		if !hasParent {
+			continue
+		}
+		attributes := ""
+		childScore, childKnown := snapshot.Scores[node]
+		parentScore, parentKnown := snapshot.Scores[parent]
+		if childKnown && parentKnown {
+			attributes = "label=" + strconv.Quote(fmt.Sprintf("%g", childScore.GScore-parentScore.GScore))
+		}
+		if pathEdges[node] {
+			if attributes != "" {
+				attributes += ", "
+			}
+			attributes += "color=\"#d62728\", penwidth=2"
+		}
+		if attributes != "" {
+			attributes = " [" + attributes + "]"
+		}
+		fmt.Fprintf(output, "\tn%d -> n%d%s;\n", ids[parent], ids[node], attributes)
+	}
+	fmt.Fprintln(output, "}")
+	return output.Flush()
+}
@@ -0,0 +1,53 @@
+package astar
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestWriteDOTGolden writes a small search tree whose labels collide, so the
+// order also depends on the tie-break, and compares it with the expected
+// DOT several times to catch map order leaking into the output.
+func TestWriteDOTGolden(t *testing.T) {
+	snapshot := StepSnapshot[testPoint]{
+		Open:   map[testPoint]bool{{0, 1}: true, {1, 1}: true, {2, 0}: true},
+		Closed: map[testPoint]bool{{0, 0}: true, {1, 0}: true},
+		CameFrom: map[testPoint]testPoint{
+			{1, 0}: {0, 0}, {0, 1}: {0, 0}, {1, 1}: {1, 0}, {2, 0}: {1, 0},
+		},
+		Scores: map[testPoint]NodeScore{
+			{0, 0}: {GScore: 0, HScore: 2, FCost: 2},
+			{1, 0}: {GScore: 1, HScore: 1, FCost: 2},
+			{0, 1}: {GScore: 1, HScore: 3, FCost: 4},
+			{1, 1}: {GScore: 3.5, HScore: 2, FCost: 5.5},
+			{2, 0}: {GScore: 2, HScore: 0, FCost: 2},
+		},
+		Path: []testPoint{{0, 0}, {1, 0}, {2, 0}},
+	}
+	// Label by column only, so two pairs of nodes share a label.
+	column := func(node testPoint) string { return fmt.Sprintf("x%d", node[0]) }
+	const want = `digraph search {
+	node [shape=ellipse, fontname="Helvetica"];
+	edge [fontname="Helvetica", fontsize=10];
+	n0 [label="x0\ng=0 h=2 f=2", style=filled, fillcolor="#d9d9d9", color="#d62728", penwidth=2];
+	n1 [label="x0\ng=1 h=3 f=4", style=dashed, color="#1f77b4"];
+	n2 [label="x1\ng=1 h=1 f=2", style=filled, fillcolor="#d9d9d9", color="#d62728", penwidth=2];
+	n3 [label="x1\ng=3.5 h=2 f=5.5", style=dashed, color="#1f77b4"];
+	n4 [label="x2\ng=2 h=0 f=2", style=dashed, color="#d62728", penwidth=2];
+	n0 -> n1 [label="1"];
+	n0 -> n2 [label="1", color="#d62728", penwidth=2];
+	n2 -> n3 [label="2.5"];
+	n2 -> n4 [label="1", color="#d62728", penwidth=2];
+}
+`
+	for range 20 {
+		var written strings.Builder
+		if err := WriteDOT(&written, snapshot, column); err != nil {
+			t.Fatal(err)
+		}
+		if written.String() != want {
+			t.Fatalf("got\n%s\nwant\n%s", written.String(), want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package astar
+
+import "context"
+
+// PredecessorGraph is implemented by directed graphs that can list the
+// edges leading into a node. A Stepper uses it to repair its state after a
+// graph edit; graphs without it are assumed to have symmetric edges, so that
+// Neighbors also lists the predecessors.
+type PredecessorGraph[NodeType comparable] interface {
+	Predecessors(node NodeType) []Neighbor[NodeType]
+}
+
+// predecessorsOf returns the nodes that may have an edge into node.
+func predecessorsOf[NodeType comparable](contextObject context.Context, graph Graph[NodeType], node NodeType) ([]Neighbor[NodeType], error) {
+	if adapter, isAdapter := graph.(contextGraph[NodeType]); isAdapter {
+		if predecessors, directed := adapter.graph.(PredecessorGraph[NodeType]); directed {
+			return predecessors.Predecessors(node), nil
+		}
+	}
+	if predecessors, directed := graph.(PredecessorGraph[NodeType]); directed {
+		return predecessors.Predecessors(node), nil
+	}
+	return neighborsOf(contextObject, graph, node)
+}
+
+// InvalidateNode tells the stepper that node, or the edges around it,
+// changed in the graph. Every node whose best known path runs through node
+// is forgotten, node included, and the closed nodes next to them are opened
+// again so that the following steps find their new best paths. A finished
+// search resumes. Graph edits cannot be undone: they clear the history of
+// StepBack.
+func (s *Stepper[NodeType]) InvalidateNode(node NodeType) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.endEdit()
+	return s.invalidate(node)
+}
+
+// AddObstacle blocks node: the search never enters it again, whether or not
+// the graph still lists it as a neighbor. The paths through node are
+// repaired as by InvalidateNode.
+func (s *Stepper[NodeType]) AddObstacle(node NodeType) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.endEdit()
+	if s.orchestrator.blocked == nil {
+		s.orchestrator.blocked = map[NodeType]bool{}
+	}
+	s.orchestrator.blocked[node] = true
+	return s.invalidate(node)
+}
+
+// UpdateEdge tells the stepper that the edge from -> to now costs cost in
+// the graph. If the best known path to to used the edge, or the new cost
+// makes from a better predecessor, the search repairs its state as by
+// InvalidateNode and expands from again. Closed nodes outside the repaired
+// region keep their paths even if the cheaper edge shortens them; such
+// cases are counted in Statistics.Reopenings.
+func (s *Stepper[NodeType]) UpdateEdge(from, to NodeType, cost float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.endEdit()
+	fromG, fromReached := s.state.gScores[from]
+	toG, toReached := s.state.gScores[to]
+	parent, hasParent := s.state.cameFrom[to]
+	throughEdge := hasParent && parent == from
+	improves := fromReached && (!toReached || fromG+cost < toG)
+	if !throughEdge && !improves {
+		return nil
+	}
+	if throughEdge || s.state.closedSet[to] {
+		if err := s.invalidate(to); err != nil {
+			return err
+		}
+	}
+	if s.state.closedSet[from] {
+		if err := s.prepareEdit(); err != nil {
+			return err
+		}
+		return s.reopenNode(from)
+	}
+	return nil
+}
+
+// invalidate forgets node and the nodes reached through it, then reopens
+// their closed predecessors.
+func (s *Stepper[NodeType]) invalidate(node NodeType) error {
+	if _, reached := s.state.gScores[node]; !reached {
+		// Nothing was derived from node yet.
+		return nil
+	}
+	if err := s.prepareEdit(); err != nil {
+		return err
+	}
+	o := s.orchestrator
+	forgotten := s.subtree(node)
+	for _, lost := range forgotten {
+		if item, inOpen := s.state.openSetMap[lost]; inOpen {
+			o.openSet.(undoableOpenList[NodeType]).removeItem(item)
+			delete(s.state.openSetMap, lost)
+		}
+		delete(s.state.closedSet, lost)
+		delete(s.state.gScores, lost)
+		delete(s.state.cameFrom, lost)
+		delete(s.recorder.scores, lost)
+	}
+	s.recorder.delta.Invalidated = append(s.recorder.delta.Invalidated, forgotten...)
+
+	isForgotten := make(map[NodeType]bool, len(forgotten))
+	for _, lost := range forgotten {
+		isForgotten[lost] = true
+	}
+	if isForgotten[o.startNode] {
+		// Put the start node back as begin does.
+		h, err := safeHeuristic(o.heuristic, o.startNode, o.goalNode)
+		if err != nil {
+			return err
+		}
+		if !o.blocked[o.startNode] {
+			o.state.setGScore(o.startNode, 0.0)
+			o.push(o.newItem(o.startNode, 0.0, h))
+		}
+	}
+	for _, lost := range forgotten {
+		predecessors, err := predecessorsOf(s.ctx, o.graph, lost)
+		if err != nil {
+			return err
+		}
+		for _, predecessor := range predecessors {
+			if !isForgotten[predecessor.ID] && s.state.closedSet[predecessor.ID] {
+				if err := s.reopenNode(predecessor.ID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// subtree lists node and every node whose best known path runs through it.
+func (s *Stepper[NodeType]) subtree(node NodeType) []NodeType {
+	children := map[NodeType][]NodeType{}
+	for child, parent := range s.state.cameFrom {
+		children[parent] = append(children[parent], child)
+	}
+	nodes := []NodeType{node}
+	for i := 0; i < len(nodes); i++ {
+		nodes = append(nodes, children[nodes[i]]...)
+	}
+	return nodes
+}
+
+// reopenNode moves a closed node back to the open set with its g-score.
+func (s *Stepper[NodeType]) reopenNode(node NodeType) error {
+	o := s.orchestrator
+	h, err := safeHeuristic(o.heuristic, node, o.goalNode)
+	if err != nil {
+		return err
+	}
+	delete(s.state.closedSet, node)
+	gScore := s.state.gScores[node]
+	o.push(o.newItem(node, gScore, gScore+h))
+	return nil
+}
+
+// prepareEdit drops the history, which cannot undo edits, and resumes a
+// finished search. The goal of a successful search is opened again: the
+// edit may have made a cheaper path to it.
+func (s *Stepper[NodeType]) prepareEdit() error {
+	clear(s.history)
+	s.history = s.history[:0]
+	if !s.done {
+		return nil
+	}
+	found := s.found
+	s.done, s.found = false, false
+	s.reopen()
+	if found && s.state.closedSet[s.orchestrator.goalNode] {
+		return s.reopenNode(s.orchestrator.goalNode)
+	}
+	return nil
+}
+
+// endEdit drops the journal entries written by an edit. Edits are a barrier
+// for StepBack, so they must not be undone with the step that follows.
+func (s *Stepper[NodeType]) endEdit() {
+	s.journal.take()
+}
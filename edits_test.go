@@ -0,0 +1,72 @@
+package astar
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+)
+
+// TestEditThenStepBack checks that an edit is a barrier for StepBack: the
+// step after it undoes to the state the edit left, and no further.
+func TestEditThenStepBack(t *testing.T) {
+	grid := newTestGrid(20, 20, 0.2, 5)
+	start, goal := testPoint{0, 0}, testPoint{19, 19}
+	edits := map[string]func(stepper *Stepper[testPoint], node testPoint) error{
+		"AddObstacle":    (*Stepper[testPoint]).AddObstacle,
+		"InvalidateNode": (*Stepper[testPoint]).InvalidateNode,
+	}
+	for name, edit := range edits {
+		for _, test := range openListKinds {
+			t.Run(name+"/"+test.name, func(t *testing.T) {
+				stepper := NewStepper(context.Background(), grid, start, goal, manhattan,
+					WithWorkers(2), WithDeterministic(), WithOpenList(test.kind), WithHistoryLimit(-1))
+				defer stepper.Close()
+				if _, err := stepper.StepN(30); err != nil {
+					t.Fatal(err)
+				}
+				before := stepper.Snapshot()
+				node := before.CameFrom[before.Current]
+				if err := edit(stepper, node); err != nil {
+					t.Fatal(err)
+				}
+				afterEdit := stepper.Snapshot()
+				if _, err := stepper.Step(); err != nil {
+					t.Fatal(err)
+				}
+				snapshot, err := stepper.StepBack()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !sameState(snapshot, afterEdit) {
+					t.Fatalf("StepBack after %s: open %d closed %d, want open %d closed %d",
+						name, len(snapshot.Open), len(snapshot.Closed), len(afterEdit.Open), len(afterEdit.Closed))
+				}
+				if _, err := stepper.StepBack(); !errors.Is(err, ErrNoHistory) {
+					t.Fatalf("StepBack across %s: err = %v, want ErrNoHistory", name, err)
+				}
+
+				want := grid
+				if name == "AddObstacle" {
+					want.walls = maps.Clone(grid.walls)
+					want.walls[node] = true
+				}
+				result, err := Search(context.Background(), want, start, goal, manhattan, WithWorkers(1))
+				if err != nil {
+					t.Fatal(err)
+				}
+				snapshot, err = stepper.RunUntil(func(StepSnapshot[testPoint]) bool { return false })
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !snapshot.Found || len(snapshot.Path)-1 != int(result.TotalCost) {
+					t.Fatalf("found %v with %d moves, want %g", snapshot.Found, len(snapshot.Path)-1, result.TotalCost)
+				}
+				if name == "AddObstacle" && slices.Contains(snapshot.Path, node) {
+					t.Fatalf("path %v goes through the obstacle %v", snapshot.Path, node)
+				}
+			})
+		}
+	}
+}
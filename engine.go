@@ -0,0 +1,88 @@
+package astar
+
+import (
+	"context"
+	"sync"
+)
+
+// Engine runs searches on a long-lived worker pool.
+//
+// Unlike Search, which starts and stops a pool for every call, an Engine
+// starts its workers once and shares them between queries. It also keeps the
+// per-search state of finished queries for reuse. An Engine is safe for
+// concurrent use; Close stops the workers once it is no longer needed.
+type Engine[NodeType comparable] struct {
+	options       Options
+	pool          *workerPool[NodeType]
+	mapStates     sync.Pool
+	indexedStates sync.Pool
+}
+
+// NewEngine starts an engine whose workers are shared by all its searches.
+// The options become the defaults of every query run by the engine.
+func NewEngine[NodeType comparable](options ...Option) *Engine[NodeType] {
+	engineOptions := applyOptions(defaultOptions(), options)
+	engine := &Engine[NodeType]{
+		options: engineOptions,
+		pool:    newWorkerPool[NodeType](engineOptions.NumberOfWorkers),
+	}
+	engine.mapStates.New = func() any { return newMapState[NodeType]() }
+	engine.indexedStates.New = func() any { return NewIndexedState[NodeType](0) }
+	return engine
+}
+
+// Search runs one query like the package-level Search, using the engine's
+// workers. Options passed here are applied on top of the engine's options;
+// WithWorkers has no effect since the pool size is fixed by NewEngine.
+func (engine *Engine[NodeType]) Search(
+	contextObject context.Context,
+	graph Graph[NodeType],
+	startNode NodeType,
+	goalNode NodeType,
+	heuristic Heuristic[NodeType],
+	options ...Option,
+) (Result[NodeType], error) {
+	if engine.closed() {
+		return Result[NodeType]{}, ErrEngineClosed
+	}
+	searchOptions := applyOptions(engine.options, options)
+
+	if indexer, isIndexed := indexerOf(graph); isIndexed {
+		state := engine.indexedStates.Get().(*IndexedState[NodeType])
+		defer engine.indexedStates.Put(state)
+		state.begin(indexer)
+		return search(contextObject, graph, state, engine.pool, startNode, goalNode, heuristic, searchOptions)
+	}
+	state := engine.mapStates.Get().(*mapState[NodeType])
+	defer engine.mapStates.Put(state)
+	state.reset()
+	return search(contextObject, graph, state, engine.pool, startNode, goalNode, heuristic, searchOptions)
+}
+
+// NewStepper creates a Stepper that expands nodes on the engine's workers.
+// Closing the stepper leaves the engine running.
+func (engine *Engine[NodeType]) NewStepper(
+	parent context.Context,
+	graph Graph[NodeType],
+	startNode NodeType,
+	goalNode NodeType,
+	heuristic Heuristic[NodeType],
+	options ...Option,
+) *Stepper[NodeType] {
+	return newStepper(parent, graph, engine.pool, startNode, goalNode, heuristic, applyOptions(engine.options, options))
+}
+
+// Close stops the engine's workers. Searches still running, paused ones
+// included, return ErrEngineClosed.
+func (engine *Engine[NodeType]) Close() {
+	engine.pool.close()
+}
+
+func (engine *Engine[NodeType]) closed() bool {
+	select {
+	case <-engine.pool.quit:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,85 @@
+package astar
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+var (
+	// ErrEngineClosed is returned by searches on an Engine after Close.
+	ErrEngineClosed = errors.New("engine closed")
+	// ErrBudgetExceeded is returned when a search hits one of the limits set
+	// by WithMaxExpansions, WithMaxCost or WithDeadline. The Result returned
+	// with it is partial.
+	ErrBudgetExceeded = errors.New("search budget exceeded")
+	// ErrNoPath is returned when the open set runs empty before the goal is
+	// reached.
+	ErrNoPath = errors.New("no path found")
+	// ErrInvalidStart is returned when the start node is outside the index
+	// range of an Indexer graph.
+	ErrInvalidStart = errors.New("invalid start node")
+	// ErrInvalidGoal is returned when the goal node is outside the index
+	// range of an Indexer graph.
+	ErrInvalidGoal = errors.New("invalid goal node")
+	// ErrNegativeCost is returned, wrapped with the offending edge, when
+	// Graph.Neighbors reports a negative or NaN cost.
+	ErrNegativeCost = errors.New("negative edge cost")
+	// ErrInvalidHeuristic is returned, wrapped with the node, when the
+	// Heuristic returns a negative or NaN estimate. +Inf is allowed and marks
+	// a node from which the goal cannot be reached.
+	ErrInvalidHeuristic = errors.New("invalid heuristic estimate")
+	// ErrInvalidCheckpoint is returned, wrapped with details, when a
+	// checkpoint cannot be read or does not describe a consistent search.
+	ErrInvalidCheckpoint = errors.New("invalid checkpoint")
+	// ErrNoHistory is returned by Stepper.StepBack and Stepper.Rewind when
+	// the steps to undo are not in the history.
+	ErrNoHistory = errors.New("no step history")
+	// ErrInvalidTrace is returned, wrapped with details, when a trace cannot
+	// be read.
+	ErrInvalidTrace = errors.New("invalid trace")
+)
+
+// PanicError reports a panic raised by Graph.Neighbors or by the Heuristic.
+// The search that hit it stops and returns the PanicError instead of crashing
+// the process.
+type PanicError[NodeType comparable] struct {
+	// Node is the node being expanded when Neighbors panicked, or the node
+	// whose estimate was being computed when the Heuristic panicked.
+	Node NodeType
+	// Func is "Neighbors" or "Heuristic".
+	Func string
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+func (e *PanicError[NodeType]) Error() string {
+	return fmt.Sprintf("panic in %s for node %v: %v", e.Func, e.Node, e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError[NodeType]) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recoverPanic turns a panic into a *PanicError stored in errorOut. It must
+// be called directly by a deferred function.
+func recoverPanic[NodeType comparable](node NodeType, function string, errorOut *error) {
+	if value := recover(); value != nil {
+		*errorOut = &PanicError[NodeType]{Node: node, Func: function, Value: value, Stack: debug.Stack()}
+	}
+}
+
+// safeHeuristic calls heuristic, recovering a panic as a *PanicError and
+// rejecting estimates the open lists cannot order.
+func safeHeuristic[NodeType comparable](heuristic Heuristic[NodeType], from, to NodeType) (estimate float64, err error) {
+	defer recoverPanic(from, "Heuristic", &err)
+	estimate = heuristic(from, to)
+	if !(estimate >= 0) {
+		return 0, fmt.Errorf("%w: h(%v) = %v", ErrInvalidHeuristic, from, estimate)
+	}
+	return estimate, nil
+}
@@ -0,0 +1,89 @@
+package astar
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+var errCorruptTile = errors.New("corrupt tile")
+
+// panickingGrid panics when asked for the neighbors of one node.
+type panickingGrid struct {
+	testGrid
+	panicAt testPoint
+}
+
+func (grid panickingGrid) Neighbors(node testPoint) []Neighbor[testPoint] {
+	if node == grid.panicAt {
+		panic(errCorruptTile)
+	}
+	return grid.testGrid.Neighbors(node)
+}
+
+// TestSearchRecoversPanics checks that panics in the Heuristic and in
+// Neighbors come back as a *PanicError naming the function and the node.
+func TestSearchRecoversPanics(t *testing.T) {
+	grid := newTestGrid(10, 10, 0, 1)
+	start, goal, bad := testPoint{0, 0}, testPoint{9, 0}, testPoint{2, 0}
+	heuristic := func(from, to testPoint) float64 {
+		if from == bad {
+			panic("bad estimate")
+		}
+		return manhattan(from, to)
+	}
+	tests := []struct {
+		function  string
+		graph     Graph[testPoint]
+		heuristic Heuristic[testPoint]
+	}{
+		{"Heuristic", grid, heuristic},
+		{"Neighbors", panickingGrid{grid, bad}, manhattan},
+	}
+	for _, test := range tests {
+		_, err := Search(context.Background(), test.graph, start, goal, test.heuristic, WithWorkers(2))
+		var panicError *PanicError[testPoint]
+		if !errors.As(err, &panicError) {
+			t.Fatalf("%s: error %v, want a *PanicError", test.function, err)
+		}
+		if panicError.Func != test.function || panicError.Node != bad || len(panicError.Stack) == 0 {
+			t.Fatalf("%s: got Func %q, Node %v", test.function, panicError.Func, panicError.Node)
+		}
+	}
+}
+
+// TestSearchLeavesNoGoroutines checks that Search stops its workers when it
+// finds a path, runs out of nodes and fails.
+func TestSearchLeavesNoGoroutines(t *testing.T) {
+	grid := newTestGrid(20, 20, 0, 1)
+	walled := newTestGrid(20, 20, 0, 1)
+	walled.walls[testPoint{18, 19}] = true
+	walled.walls[testPoint{19, 18}] = true
+	start, goal := testPoint{0, 0}, testPoint{19, 19}
+	tests := []struct {
+		name  string
+		graph Graph[testPoint]
+		want  error
+	}{
+		{"found", grid, nil},
+		{"no path", walled, ErrNoPath},
+		// Every search expands its start node.
+		{"error", panickingGrid{grid, start}, errCorruptTile},
+	}
+	for _, test := range tests {
+		baseline := runtime.NumGoroutine()
+		_, err := Search(context.Background(), test.graph, start, goal, manhattan, WithWorkers(8))
+		if !errors.Is(err, test.want) {
+			t.Fatalf("%s: error %v, want %v", test.name, err, test.want)
+		}
+		deadline := time.Now().Add(2 * time.Second)
+		for runtime.NumGoroutine() > baseline {
+			if time.Now().After(deadline) {
+				t.Fatalf("%s: %d goroutines after Search, %d before", test.name, runtime.NumGoroutine(), baseline)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Command tracediff reports the first divergence between two search traces
+// written by astar.TraceWriter:
+//
+//	tracediff before.jsonl after.jsonl
+//
+// It exits with status 1 when the traces differ.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	astar "github.com/pdrpinto/astar"
+)
+
+// rawNode keeps a node as its compact JSON text, so traces of any node type
+// can be compared.
+type rawNode string
+
+func (n *rawNode) UnmarshalJSON(data []byte) error {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return err
+	}
+	*n = rawNode(compact.String())
+	return nil
+}
+
+func (n rawNode) String() string { return string(n) }
+
+func readTrace(name string) *astar.Trace[rawNode] {
+	file, err := os.Open(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+	trace, err := astar.ReadTrace[rawNode](file)
+	if err != nil {
+		log.Fatalf("%s: %v", name, err)
+	}
+	return trace
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) != 3 {
+		log.Fatal("usage: tracediff a.jsonl b.jsonl")
+	}
+	a, b := readTrace(os.Args[1]), readTrace(os.Args[2])
+	if a.Header.Start != b.Header.Start || a.Header.Goal != b.Header.Goal {
+		fmt.Printf("warning: different queries: %v -> %v vs %v -> %v\n", a.Header.Start, a.Header.Goal, b.Header.Start, b.Header.Goal)
+	}
+	divergence := astar.DiffTraces(a, b)
+	if divergence == nil {
+		fmt.Printf("identical: %d events\n", len(a.Events))
+		return
+	}
+	fmt.Println(divergence)
+	os.Exit(1)
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	astar "github.com/pdrpinto/astar"
@@ -91,6 +92,20 @@ func (gg gridGraph) Neighbors(p point) []astar.Neighbor[point] {
 	return out
 }
 
+// stepDelta is the reply of /next: only the cells changed by the step.
+type stepDelta struct {
+	Step    int      `json:"step"`
+	Current [2]int   `json:"current"`
+	Added   [][2]int `json:"added,omitempty"`
+	Closed  [][2]int `json:"closed,omitempty"`
+	// Invalidated lists cells forgotten because of walls drawn mid-search.
+	Invalidated [][2]int `json:"invalidated,omitempty"`
+	Done        bool     `json:"done"`
+	Found       bool     `json:"found"`
+	Path        [][2]int `json:"path,omitempty"`
+}
+
+// snapshot is the reply of /state: the whole grid and search state.
 type snapshot struct {
 	Step    int      `json:"step"`
 	W       int      `json:"w"`
@@ -132,12 +147,51 @@ func mapKeysBool(m map[point]bool) [][2]int {
 	return res
 }
 
+// mu guards the current session, which /init replaces while other
+// handlers may be using it. The stepper itself is safe for concurrent use.
 var (
+	mu                    sync.Mutex
 	gState                grid
 	startState, goalState point
 	stepper               *astar.Stepper[point]
+	// drawn holds the walls drawn during the session. They reach the
+	// search through AddObstacle; gState.Walls is never modified because the
+	// workers read it concurrently.
+	drawn map[point]bool
 )
 
+// session returns the current grid, endpoints and stepper.
+func session() (grid, point, point, *astar.Stepper[point]) {
+	mu.Lock()
+	defer mu.Unlock()
+	return gState, startState, goalState, stepper
+}
+
+// handleWall adds a wall at x, y to the running search.
+func handleWall(w http.ResponseWriter, r *http.Request) {
+	g, start, goal, stepper := session()
+	if stepper == nil {
+		http.Error(w, "engine not initialized", http.StatusBadRequest)
+		return
+	}
+	x, errX := strconv.Atoi(r.URL.Query().Get("x"))
+	y, errY := strconv.Atoi(r.URL.Query().Get("y"))
+	p := point{x, y}
+	if errX != nil || errY != nil || !g.in(p) || p == start || p == goal {
+		http.Error(w, "invalid cell", http.StatusBadRequest)
+		return
+	}
+	if err := stepper.AddObstacle(p); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	mu.Lock()
+	drawn[p] = true
+	mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+}
+
 func handleInit(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 	wStr, hStr := q.Get("w"), q.Get("h")
@@ -174,42 +228,84 @@ func handleInit(w http.ResponseWriter, r *http.Request) {
 	// ensure start/goal are not walls
 	delete(g.Walls, start)
 	delete(g.Walls, goal)
+	// create stepper
+	next := astar.NewStepper(context.Background(), gridGraph{g}, start, goal, manhattan, astar.WithWorkers(4), astar.WithDeltaSnapshots())
+	next.Subscribe(func(st astar.StepSnapshot[point]) {
+		if st.Done {
+			log.Printf("search finished after %d steps, found=%v", st.StepIndex, st.Found)
+		}
+	})
+	mu.Lock()
+	previous := stepper
 	gState = g
 	startState, goalState = start, goal
-	// create stepper
+	stepper = next
+	drawn = map[point]bool{}
+	mu.Unlock()
 	// stop previous stepper if any
-	if stepper != nil {
-		stepper.Close()
+	if previous != nil {
+		previous.Close()
 	}
-	stepper = astar.NewStepper(context.Background(), gridGraph{g}, start, goal, manhattan, astar.WithWorkers(4))
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "w": wVal, "h": hVal})
 }
 
-func handleNext(w http.ResponseWriter, r *http.Request) {
+// handleState returns the full state, used to draw the grid from scratch.
+func handleState(w http.ResponseWriter, r *http.Request) {
+	gState, startState, goalState, stepper := session()
 	if stepper == nil {
 		http.Error(w, "engine not initialized", http.StatusBadRequest)
 		return
 	}
-	st, err := stepper.Step()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	// convert snapshot
+	st := stepper.Snapshot()
+	mu.Lock()
+	walls := mapToList(gState.Walls)
+	walls = append(walls, mapToList(drawn)...)
+	mu.Unlock()
 	s := snapshot{
 		Step: st.StepIndex,
 		W:    gState.W, H: gState.H,
-		Walls: mapToList(gState.Walls),
+		Walls: walls,
 		Start: startState, Goal: goalState,
 		Done: st.Done, Found: st.Found,
+		Current: st.Current,
+		Open:    mapKeysBool(st.Open),
+		Closed:  setToList(st.Closed),
 	}
-	s.Current = st.Current
-	if st.Open != nil {
-		s.Open = mapKeysBool(st.Open)
+	for _, p := range st.Path {
+		s.Path = append(s.Path, p)
 	}
-	if st.Closed != nil {
-		s.Closed = setToList(st.Closed)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s)
+}
+
+func handleNext(w http.ResponseWriter, r *http.Request) {
+	_, _, _, stepper := session()
+	if stepper == nil {
+		http.Error(w, "engine not initialized", http.StatusBadRequest)
+		return
+	}
+	st, err := stepper.Step()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// convert the step delta
+	s := stepDelta{
+		Step:    st.StepIndex,
+		Current: st.Current,
+		Done:    st.Done, Found: st.Found,
+	}
+	if st.Delta != nil {
+		for _, n := range st.Delta.Added {
+			s.Added = append(s.Added, n.Node)
+		}
+		for _, p := range st.Delta.Closed {
+			s.Closed = append(s.Closed, p)
+		}
+		for _, p := range st.Delta.Invalidated {
+			s.Invalidated = append(s.Invalidated, p)
+		}
 	}
 	if st.Found && len(st.Path) > 0 {
 		s.Path = make([][2]int, 0, len(st.Path))
@@ -246,6 +342,8 @@ func main() {
 	mux.HandleFunc("/", handleStatic)
 	mux.HandleFunc("/init", handleInit)
 	mux.HandleFunc("/next", handleNext)
+	mux.HandleFunc("/state", handleState)
+	mux.HandleFunc("/wall", handleWall)
 	srv := &http.Server{Handler: mux}
 	// Try 8080 first, then fall back to a random free port
 	ln, err := net.Listen("tcp", ":8080")
@@ -0,0 +1,48 @@
+package astar
+
+import "math/rand"
+
+type testPoint = [2]int
+
+// testGrid is a 4-connected grid with unit costs, indexed row by row.
+type testGrid struct {
+	width, height int
+	walls         map[testPoint]bool
+}
+
+// newTestGrid returns a grid with a fraction of random walls, keeping the
+// corners free.
+func newTestGrid(width, height int, density float64, seed int64) testGrid {
+	rng := rand.New(rand.NewSource(seed))
+	grid := testGrid{width: width, height: height, walls: map[testPoint]bool{}}
+	for x := range width {
+		for y := range height {
+			if rng.Float64() < density {
+				grid.walls[testPoint{x, y}] = true
+			}
+		}
+	}
+	delete(grid.walls, testPoint{0, 0})
+	delete(grid.walls, testPoint{width - 1, height - 1})
+	return grid
+}
+
+func (grid testGrid) Neighbors(node testPoint) []Neighbor[testPoint] {
+	var neighbors []Neighbor[testPoint]
+	for _, step := range []testPoint{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+		next := testPoint{node[0] + step[0], node[1] + step[1]}
+		if next[0] < 0 || next[1] < 0 || next[0] >= grid.width || next[1] >= grid.height || grid.walls[next] {
+			continue
+		}
+		neighbors = append(neighbors, Neighbor[testPoint]{ID: next, Cost: 1})
+	}
+	return neighbors
+}
+
+func (grid testGrid) Index(node testPoint) int { return node[1]*grid.width + node[0] }
+func (grid testGrid) Len() int                 { return grid.width * grid.height }
+
+func manhattan(a, b testPoint) float64 {
+	dx, dy := a[0]-b[0], a[1]-b[1]
+	return float64(max(dx, -dx) + max(dy, -dy))
+}
@@ -0,0 +1,242 @@
+package astar
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SearchHandle controls a search started with Start. Its methods are safe
+// for concurrent use.
+type SearchHandle[NodeType comparable] struct {
+	orchestrator *orchestrator[NodeType]
+	gate         pauseGate
+	cancel       context.CancelFunc
+	started      time.Time
+
+	done   chan struct{}
+	result Result[NodeType]
+	err    error
+	// final is the snapshot taken when the search ended, before its state
+	// was released. Snapshot returns it once done is closed.
+	final SearchSnapshot[NodeType]
+}
+
+// SearchSnapshot describes a search controlled by a SearchHandle.
+type SearchSnapshot[NodeType comparable] struct {
+	ExpandedNodes int
+	OpenSize      int
+	// MinF is the lowest f-cost in the open set, a lower bound on the cost
+	// of the optimal path when the heuristic is admissible.
+	MinF float64
+	// Best is the path to the open node with the lowest heuristic estimate,
+	// as in a partial result. Once the search is done it is the final result.
+	Best   Result[NodeType]
+	Stats  Statistics
+	Paused bool
+	Done   bool
+}
+
+// Start runs a search like Search in a new goroutine and returns a handle to
+// pause, resume, cancel, inspect and wait for it. The search keeps its worker
+// pool until it ends; a paused search holds its state but uses no CPU.
+func Start[NodeType comparable](
+	contextObject context.Context,
+	graph Graph[NodeType],
+	startNode NodeType,
+	goalNode NodeType,
+	heuristic Heuristic[NodeType],
+	options ...Option,
+) *SearchHandle[NodeType] {
+	searchOptions := applyOptions(defaultOptions(), options)
+	pool := newWorkerPool[NodeType](searchOptions.NumberOfWorkers)
+
+	return startSearch(contextObject, graph, newMapState[NodeType](), pool, startNode, goalNode, heuristic, searchOptions, pool.close)
+}
+
+// Start runs a query like Start, using the engine's workers.
+func (engine *Engine[NodeType]) Start(
+	contextObject context.Context,
+	graph Graph[NodeType],
+	startNode NodeType,
+	goalNode NodeType,
+	heuristic Heuristic[NodeType],
+	options ...Option,
+) *SearchHandle[NodeType] {
+	searchOptions := applyOptions(engine.options, options)
+
+	if indexer, isIndexed := indexerOf(graph); isIndexed {
+		state := engine.indexedStates.Get().(*IndexedState[NodeType])
+		state.begin(indexer)
+		return startSearch(contextObject, graph, state, engine.pool, startNode, goalNode, heuristic, searchOptions,
+			func() { engine.indexedStates.Put(state) })
+	}
+	state := engine.mapStates.Get().(*mapState[NodeType])
+	state.reset()
+	return startSearch(contextObject, graph, state, engine.pool, startNode, goalNode, heuristic, searchOptions,
+		func() { engine.mapStates.Put(state) })
+}
+
+// startSearch runs the query in a goroutine and calls release once it ends.
+func startSearch[NodeType comparable](
+	contextObject context.Context,
+	graph Graph[NodeType],
+	state nodeState[NodeType],
+	pool *workerPool[NodeType],
+	startNode NodeType,
+	goalNode NodeType,
+	heuristic Heuristic[NodeType],
+	searchOptions Options,
+	release func(),
+) *SearchHandle[NodeType] {
+	contextObject, cancel := context.WithCancel(contextObject)
+	handle := &SearchHandle[NodeType]{
+		orchestrator: newOrchestrator(graph, state, pool, startNode, goalNode, heuristic, searchOptions),
+		cancel:       cancel,
+		started:      time.Now(),
+		done:         make(chan struct{}),
+	}
+	handle.gate.resumed = sync.NewCond(&handle.gate.mu)
+	handle.gate.quit = pool.quit
+	go func() {
+		// Wake the search if it is cancelled or its engine is closed while
+		// paused.
+		stop := context.AfterFunc(contextObject, handle.gate.wake)
+		searchEnded := make(chan struct{})
+		go func() {
+			select {
+			case <-pool.quit:
+				handle.gate.wake()
+			case <-searchEnded:
+			}
+		}()
+		result, err := handle.orchestrator.run(contextObject, &handle.gate)
+		stop()
+		close(searchEnded)
+		cancel()
+		handle.gate.mu.Lock()
+		handle.result, handle.err = result, err
+		handle.final = handle.describe(true)
+		handle.gate.mu.Unlock()
+		close(handle.done)
+		// The state may go to another query of the engine only once Snapshot
+		// no longer reads it.
+		release()
+	}()
+	return handle
+}
+
+// Pause suspends the search after the expansion in progress. When Pause
+// returns, the search no longer changes until Resume or Cancel is called.
+func (handle *SearchHandle[NodeType]) Pause() {
+	handle.gate.mu.Lock()
+	handle.gate.paused = true
+	handle.gate.mu.Unlock()
+}
+
+// Resume continues a paused search.
+func (handle *SearchHandle[NodeType]) Resume() {
+	handle.gate.mu.Lock()
+	handle.gate.paused = false
+	handle.gate.mu.Unlock()
+	handle.gate.wake()
+}
+
+// Cancel stops the search, paused or not. Wait then returns the context
+// error.
+func (handle *SearchHandle[NodeType]) Cancel() {
+	handle.cancel()
+}
+
+// Wait blocks until the search ends and returns its result.
+func (handle *SearchHandle[NodeType]) Wait() (Result[NodeType], error) {
+	<-handle.done
+	return handle.result, handle.err
+}
+
+// Done is closed when the search ends.
+func (handle *SearchHandle[NodeType]) Done() <-chan struct{} {
+	return handle.done
+}
+
+// Snapshot describes the search between two expansions. On a running search
+// it waits for the expansion in progress.
+func (handle *SearchHandle[NodeType]) Snapshot() SearchSnapshot[NodeType] {
+	handle.gate.mu.Lock()
+	defer handle.gate.mu.Unlock()
+
+	select {
+	case <-handle.done:
+		snapshot := handle.final
+		snapshot.Paused = handle.gate.paused
+		return snapshot
+	default:
+		return handle.describe(false)
+	}
+}
+
+// describe reads the orchestrator, which must not be running; the caller
+// holds the gate.
+func (handle *SearchHandle[NodeType]) describe(done bool) SearchSnapshot[NodeType] {
+	o := handle.orchestrator
+	snapshot := SearchSnapshot[NodeType]{
+		ExpandedNodes: o.expandedNodes,
+		OpenSize:      o.openSet.Len(),
+		MinF:          o.minOpenF(),
+		Stats:         o.stats,
+		Paused:        handle.gate.paused,
+		Done:          done,
+	}
+	if done {
+		snapshot.Best = handle.result
+		snapshot.Stats = handle.result.Stats
+	} else {
+		snapshot.Best = o.partialResult()
+		snapshot.Stats.WallTime = time.Since(handle.started)
+	}
+	return snapshot
+}
+
+// pauseGate lets a SearchHandle hold its search between two expansions.
+// A nil gate never blocks.
+type pauseGate struct {
+	mu      sync.Mutex
+	resumed *sync.Cond
+	paused  bool
+	// quit is closed when the worker pool of the search shuts down.
+	quit <-chan struct{}
+}
+
+// enter waits until the gate is open, the context is done or the worker
+// pool is closed, and keeps the gate locked until leave.
+func (gate *pauseGate) enter(contextObject context.Context) {
+	if gate == nil {
+		return
+	}
+	gate.mu.Lock()
+	for gate.paused && contextObject.Err() == nil && !gate.closed() {
+		gate.resumed.Wait()
+	}
+}
+
+func (gate *pauseGate) closed() bool {
+	select {
+	case <-gate.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+func (gate *pauseGate) leave() {
+	if gate == nil {
+		return
+	}
+	gate.mu.Unlock()
+}
+
+func (gate *pauseGate) wake() {
+	gate.mu.Lock()
+	gate.resumed.Broadcast()
+	gate.mu.Unlock()
+}
@@ -0,0 +1,99 @@
+package astar
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSearchHandleSnapshotAfterDone takes snapshots of finished searches
+// while other queries reuse their engine state. Run with -race.
+func TestSearchHandleSnapshotAfterDone(t *testing.T) {
+	engine := NewEngine[testPoint](WithWorkers(2))
+	defer engine.Close()
+	grid := newTestGrid(40, 40, 0.2, 1)
+	goal := testPoint{39, 39}
+	want, err := engine.Search(context.Background(), grid, testPoint{0, 0}, goal, manhattan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	var wait sync.WaitGroup
+	for range 4 {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					engine.Search(context.Background(), grid, testPoint{0, 0}, goal, manhattan)
+				}
+			}
+		}()
+	}
+	defer wait.Wait()
+	defer close(stop)
+	for range 50 {
+		handle := engine.Start(context.Background(), grid, testPoint{0, 0}, goal, manhattan)
+		result, err := handle.Wait()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 100 {
+			snapshot := handle.Snapshot()
+			if !snapshot.Done || snapshot.Best.TotalCost != want.TotalCost || snapshot.ExpandedNodes != result.ExpandedNodes {
+				t.Fatalf("snapshot after Wait = %+v, want done with cost %g", snapshot, want.TotalCost)
+			}
+		}
+	}
+}
+
+// gatedGrid holds its first Neighbors call until release is closed.
+type gatedGrid struct {
+	testGrid
+	once    sync.Once
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (grid *gatedGrid) Neighbors(node testPoint) []Neighbor[testPoint] {
+	grid.once.Do(func() {
+		close(grid.entered)
+		<-grid.release
+	})
+	return grid.testGrid.Neighbors(node)
+}
+
+// TestSearchHandlePausedEngineClose checks that a paused search returns
+// ErrEngineClosed once its engine is closed.
+func TestSearchHandlePausedEngineClose(t *testing.T) {
+	engine := NewEngine[testPoint](WithWorkers(2))
+	grid := &gatedGrid{testGrid: newTestGrid(100, 100, 0, 1), entered: make(chan struct{}), release: make(chan struct{})}
+	handle := engine.Start(context.Background(), grid, testPoint{0, 0}, testPoint{99, 99}, manhattan)
+	<-grid.entered
+	// Pause waits for the expansion in progress, so let it finish.
+	close(grid.release)
+	handle.Pause()
+	if snapshot := handle.Snapshot(); snapshot.Done || !snapshot.Paused {
+		t.Fatalf("snapshot after Pause: %+v", snapshot)
+	}
+	engine.Close()
+	waited := make(chan error, 1)
+	go func() {
+		_, err := handle.Wait()
+		waited <- err
+	}()
+	select {
+	case err := <-waited:
+		if !errors.Is(err, ErrEngineClosed) {
+			t.Fatalf("Wait returned %v, want ErrEngineClosed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("paused search did not return after Engine.Close")
+	}
+}
@@ -0,0 +1,284 @@
+package astar
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// HistoryEntry describes a step kept in the history of a Stepper.
+type HistoryEntry[NodeType comparable] struct {
+	// StepIndex is the index reached by the step.
+	StepIndex int
+	Current   NodeType
+	Done      bool
+	Found     bool
+	// Delta and Relaxations are those of the step's snapshot.
+	Delta       *StepDelta[NodeType]
+	Relaxations []RelaxEvent[NodeType]
+}
+
+// WithHistoryLimit enables StepBack and Rewind on a Stepper, keeping the
+// last steps entries of history; a negative limit keeps every step. History
+// is off by default: each kept step holds its undo log and its delta, which
+// grows with the whole search.
+func WithHistoryLimit(steps int) Option {
+	return func(options *Options) { options.HistoryLimit = steps }
+}
+
+// undoKind tells which change an undoEntry reverts.
+type undoKind int
+
+const (
+	// undoNode restores the g-score, parent and scores of a node.
+	undoNode undoKind = iota
+	// undoPush takes an item out of the open set.
+	undoPush
+	// undoDecrease restores the key of an open item.
+	undoDecrease
+	// undoPop reopens a closed node.
+	undoPop
+)
+
+// undoEntry records the state a change overwrote.
+type undoEntry[NodeType comparable] struct {
+	kind undoKind
+	node NodeType
+	item *PriorityQueueItem[NodeType]
+
+	gScore    float64
+	hadGScore bool
+	parent    NodeType
+	hadParent bool
+	score     NodeScore
+	hadScore  bool
+	fCost     float64
+}
+
+// undoLog records the changes the orchestrator makes during a step. Its
+// methods do nothing on a nil log, which is the case outside a Stepper.
+type undoLog[NodeType comparable] struct {
+	state   *mapState[NodeType]
+	scores  map[NodeType]NodeScore
+	entries []undoEntry[NodeType]
+}
+
+// nodeChanging is called before the g-score and parent of node change.
+func (l *undoLog[NodeType]) nodeChanging(node NodeType) {
+	if l == nil {
+		return
+	}
+	entry := undoEntry[NodeType]{kind: undoNode, node: node}
+	entry.gScore, entry.hadGScore = l.state.gScores[node]
+	entry.parent, entry.hadParent = l.state.cameFrom[node]
+	entry.score, entry.hadScore = l.scores[node]
+	l.entries = append(l.entries, entry)
+}
+
+// pushed is called after item entered the open set.
+func (l *undoLog[NodeType]) pushed(item *PriorityQueueItem[NodeType]) {
+	if l == nil {
+		return
+	}
+	l.entries = append(l.entries, undoEntry[NodeType]{kind: undoPush, node: item.Node, item: item})
+}
+
+// decreasing is called before the key of item is decreased.
+func (l *undoLog[NodeType]) decreasing(item *PriorityQueueItem[NodeType]) {
+	if l == nil {
+		return
+	}
+	l.entries = append(l.entries, undoEntry[NodeType]{
+		kind:   undoDecrease,
+		node:   item.Node,
+		item:   item,
+		gScore: item.GScore,
+		fCost:  item.FCost,
+	})
+}
+
+// popped is called after item was taken from the open set and closed.
+func (l *undoLog[NodeType]) popped(item *PriorityQueueItem[NodeType]) {
+	if l == nil {
+		return
+	}
+	l.entries = append(l.entries, undoEntry[NodeType]{kind: undoPop, node: item.Node, item: item})
+}
+
+// take returns the entries recorded since the last call.
+func (l *undoLog[NodeType]) take() []undoEntry[NodeType] {
+	if l == nil {
+		return nil
+	}
+	entries := l.entries
+	l.entries = nil
+	return entries
+}
+
+// stepRecord holds what is needed to undo one call to Step.
+type stepRecord[NodeType comparable] struct {
+	entries []undoEntry[NodeType]
+	entry   HistoryEntry[NodeType]
+
+	// The counters before the step.
+	stepCount     int
+	current       NodeType
+	started       bool
+	done          bool
+	found         bool
+	expandedNodes int
+	nextSequence  uint64
+	lastPoppedF   float64
+	stats         Statistics
+}
+
+// beginRecord captures the counters before a step.
+func (s *Stepper[NodeType]) beginRecord() stepRecord[NodeType] {
+	o := s.orchestrator
+	return stepRecord[NodeType]{
+		stepCount:     s.stepCount,
+		current:       s.current,
+		started:       s.started,
+		done:          s.done,
+		found:         s.found,
+		expandedNodes: o.expandedNodes,
+		nextSequence:  o.nextSequence,
+		lastPoppedF:   o.lastPoppedF,
+		stats:         o.stats,
+	}
+}
+
+// endRecord adds the step that produced snapshot to the history.
+func (s *Stepper[NodeType]) endRecord(record stepRecord[NodeType], snapshot StepSnapshot[NodeType]) {
+	if s.journal == nil {
+		return
+	}
+	record.entries = s.journal.take()
+	record.entry = HistoryEntry[NodeType]{
+		StepIndex:   s.stepCount,
+		Current:     s.current,
+		Done:        s.done,
+		Found:       s.found,
+		Delta:       snapshot.Delta,
+		Relaxations: snapshot.Relaxations,
+	}
+	s.history = append(s.history, record)
+	if s.historyLimit > 0 && len(s.history) > s.historyLimit {
+		dropped := len(s.history) - s.historyLimit
+		clear(s.history[:dropped])
+		s.history = s.history[dropped:]
+	}
+}
+
+// History lists the steps that StepBack and Rewind can undo, oldest first.
+// It is empty unless WithHistoryLimit was given.
+// A step that failed appears with the index of the last successful one.
+func (s *Stepper[NodeType]) History() []HistoryEntry[NodeType] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]HistoryEntry[NodeType], len(s.history))
+	for i, record := range s.history {
+		entries[i] = record.entry
+	}
+	return entries
+}
+
+// StepBack undoes the last step, including one that ended or failed the
+// search, and returns the full snapshot of the state before it. Stepping
+// forward again expands the same node; with WithDeterministic and a
+// tie-breaking policy other than TieBreakNone the following steps repeat
+// as well. Tracers are not told about undone steps.
+func (s *Stepper[NodeType]) StepBack() (StepSnapshot[NodeType], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.history) == 0 {
+		return s.snapshot(), ErrNoHistory
+	}
+	s.undo()
+	return s.snapshot(), nil
+}
+
+// Rewind undoes steps until the search is back at stepIndex and returns the
+// full snapshot of that state.
+func (s *Stepper[NodeType]) Rewind(stepIndex int) (StepSnapshot[NodeType], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stepIndex < 0 || stepIndex > s.stepCount {
+		return s.snapshot(), fmt.Errorf("%w: cannot rewind to step %d from step %d", ErrNoHistory, stepIndex, s.stepCount)
+	}
+	if len(s.history) > 0 && s.history[0].stepCount > stepIndex {
+		return s.snapshot(), fmt.Errorf("%w: step %d is older than the history", ErrNoHistory, stepIndex)
+	}
+	for len(s.history) > 0 && s.history[len(s.history)-1].stepCount >= stepIndex {
+		s.undo()
+	}
+	return s.snapshot(), nil
+}
+
+// undo reverts the last step of the history.
+func (s *Stepper[NodeType]) undo() {
+	record := s.history[len(s.history)-1]
+	s.history[len(s.history)-1] = stepRecord[NodeType]{}
+	s.history = s.history[:len(s.history)-1]
+
+	o := s.orchestrator
+	openSet := o.openSet.(undoableOpenList[NodeType])
+	for i := len(record.entries) - 1; i >= 0; i-- {
+		entry := record.entries[i]
+		switch entry.kind {
+		case undoNode:
+			restoreEntry(s.state.gScores, entry.node, entry.gScore, entry.hadGScore)
+			restoreEntry(s.state.cameFrom, entry.node, entry.parent, entry.hadParent)
+			restoreEntry(s.recorder.scores, entry.node, entry.score, entry.hadScore)
+		case undoPush:
+			openSet.removeItem(entry.item)
+			delete(s.state.openSetMap, entry.node)
+		case undoDecrease:
+			openSet.removeItem(entry.item)
+			entry.item.GScore, entry.item.FCost = entry.gScore, entry.fCost
+			openSet.restore(entry.item)
+		case undoPop:
+			delete(s.state.closedSet, entry.node)
+			openSet.restore(entry.item)
+			s.state.openSetMap[entry.node] = entry.item
+		}
+	}
+	o.expandedNodes = record.expandedNodes
+	o.nextSequence = record.nextSequence
+	o.lastPoppedF = record.lastPoppedF
+	o.stats = record.stats
+	s.stepCount = record.stepCount
+	s.current = record.current
+	s.started = record.started
+	s.found = record.found
+	if s.done && !record.done {
+		s.reopen()
+	}
+	s.done = record.done
+	// Drop what the recorder saw of a step that failed half-way.
+	s.recorder.take()
+	s.microEvents = s.microEvents[:0]
+	s.journal.take()
+}
+
+func restoreEntry[K comparable, V any](m map[K]V, key K, value V, had bool) {
+	if had {
+		m[key] = value
+	} else {
+		delete(m, key)
+	}
+}
+
+// reopen gives a stepper that finished a new context and, if it owns its
+// workers, a new pool, so that it can step again.
+func (s *Stepper[NodeType]) reopen() {
+	s.ctx, s.cancel = context.WithCancel(s.parent)
+	if s.ownedPool == nil || s.parent.Err() != nil {
+		// The pool belongs to an Engine, or Close was called.
+		return
+	}
+	pool := newWorkerPool[NodeType](s.workers)
+	s.ownedPool = pool
+	s.orchestrator.pool = pool
+	runtime.AddCleanup(s, (*workerPool[NodeType]).close, pool)
+}
@@ -0,0 +1,90 @@
+package astar
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// sameState reports whether two full snapshots describe the same search
+// state.
+func sameState(a, b StepSnapshot[testPoint]) bool {
+	return a.Current == b.Current && a.StepIndex == b.StepIndex && a.Done == b.Done && a.Found == b.Found &&
+		reflect.DeepEqual(a.Open, b.Open) && reflect.DeepEqual(a.Closed, b.Closed) &&
+		reflect.DeepEqual(a.CameFrom, b.CameFrom) && reflect.DeepEqual(a.Scores, b.Scores)
+}
+
+// TestStepBackRoundTrip steps a search to the end, undoes every step while
+// checking each state against the one seen going forward, then replays it.
+func TestStepBackRoundTrip(t *testing.T) {
+	grid := newTestGrid(20, 20, 0.25, 3)
+	for _, test := range openListKinds {
+		t.Run(test.name, func(t *testing.T) {
+			stepper := NewStepper(context.Background(), grid, testPoint{0, 0}, testPoint{19, 19}, manhattan,
+				WithWorkers(2), WithDeterministic(), WithTieBreak(TieBreakFIFO), WithOpenList(test.kind), WithHistoryLimit(-1))
+			defer stepper.Close()
+			states := []StepSnapshot[testPoint]{stepper.Snapshot()}
+			for !states[len(states)-1].Done {
+				if _, err := stepper.Step(); err != nil {
+					t.Fatal(err)
+				}
+				states = append(states, stepper.Snapshot())
+			}
+			if got := len(stepper.History()); got != len(states)-1 {
+				t.Fatalf("History() has %d entries, want %d", got, len(states)-1)
+			}
+			for i := len(states) - 2; i >= 0; i-- {
+				snapshot, err := stepper.StepBack()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !sameState(snapshot, states[i]) {
+					t.Fatalf("state after stepping back to step %d differs", states[i].StepIndex)
+				}
+			}
+			if _, err := stepper.StepBack(); !errors.Is(err, ErrNoHistory) {
+				t.Fatalf("StepBack at the start: err = %v, want ErrNoHistory", err)
+			}
+			for i := 1; i < len(states); i++ {
+				if _, err := stepper.Step(); err != nil {
+					t.Fatal(err)
+				}
+				if !sameState(stepper.Snapshot(), states[i]) {
+					t.Fatalf("replayed step %d differs", i)
+				}
+			}
+			if _, err := stepper.Rewind(3); err != nil {
+				t.Fatal(err)
+			}
+			if !sameState(stepper.Snapshot(), states[3]) {
+				t.Fatal("state after Rewind(3) differs")
+			}
+		})
+	}
+}
+
+func TestStepBackHistoryLimit(t *testing.T) {
+	grid := newTestGrid(20, 20, 0, 0)
+	stepper := NewStepper(context.Background(), grid, testPoint{0, 0}, testPoint{19, 19}, manhattan, WithWorkers(1))
+	defer stepper.Close()
+	stepper.StepN(5)
+	if _, err := stepper.StepBack(); !errors.Is(err, ErrNoHistory) {
+		t.Fatalf("StepBack without history: err = %v, want ErrNoHistory", err)
+	}
+
+	limited := NewStepper(context.Background(), grid, testPoint{0, 0}, testPoint{19, 19}, manhattan, WithWorkers(1), WithHistoryLimit(2))
+	defer limited.Close()
+	limited.StepN(5)
+	for range 2 {
+		if _, err := limited.StepBack(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := limited.StepBack(); !errors.Is(err, ErrNoHistory) {
+		t.Fatalf("third StepBack with a limit of 2: err = %v, want ErrNoHistory", err)
+	}
+	if got := limited.Snapshot().StepIndex; got != 3 {
+		t.Fatalf("StepIndex = %d, want 3", got)
+	}
+}
@@ -0,0 +1,107 @@
+package astar
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// defaultLogInterval is the minimum time between two progress records.
+const defaultLogInterval = time.Second
+
+// WithLogger makes the search emit debug records to logger: one when it
+// starts, progress records at most once per log interval, and one when it
+// ends with the termination reason and cost. Records are only built when
+// the logger is enabled for slog.LevelDebug.
+func WithLogger(logger *slog.Logger) Option {
+	return func(options *Options) { options.Logger = logger }
+}
+
+// WithLogInterval sets the minimum time between two progress records of
+// WithLogger. The default is one second.
+func WithLogInterval(interval time.Duration) Option {
+	return func(options *Options) { options.LogInterval = interval }
+}
+
+// searchLogger writes the debug records of one search.
+type searchLogger struct {
+	logger   *slog.Logger
+	interval time.Duration
+	started  time.Time
+	lastLog  time.Time
+}
+
+func newSearchLogger(searchOptions Options) *searchLogger {
+	if searchOptions.Logger == nil {
+		return nil
+	}
+	interval := searchOptions.LogInterval
+	if interval <= 0 {
+		interval = defaultLogInterval
+	}
+	return &searchLogger{logger: searchOptions.Logger, interval: interval}
+}
+
+func (l *searchLogger) enabled(contextObject context.Context) bool {
+	return l != nil && l.logger.Enabled(contextObject, slog.LevelDebug)
+}
+
+// logStart records the beginning of the search.
+func (o *orchestrator[NodeType]) logStart(contextObject context.Context) {
+	if o.logger == nil {
+		return
+	}
+	o.logger.started = time.Now()
+	o.logger.lastLog = o.logger.started
+	if !o.logger.enabled(contextObject) {
+		return
+	}
+	o.logger.logger.LogAttrs(contextObject, slog.LevelDebug, "astar search started",
+		slog.Any("start", o.startNode),
+		slog.Any("goal", o.goalNode),
+	)
+}
+
+// logProgress records the state of the search if the log interval elapsed.
+func (o *orchestrator[NodeType]) logProgress(contextObject context.Context, now time.Time) {
+	if now.Sub(o.logger.lastLog) < o.logger.interval || !o.logger.enabled(contextObject) {
+		return
+	}
+	o.logger.lastLog = now
+	o.logger.logger.LogAttrs(contextObject, slog.LevelDebug, "astar search progress",
+		slog.Int("expanded", o.expandedNodes),
+		slog.Int("open", o.openSet.Len()),
+		slog.Float64("best_f", o.lastPoppedF),
+		slog.Duration("elapsed", now.Sub(o.logger.started)),
+	)
+}
+
+// logEnd records how the search terminated.
+func (o *orchestrator[NodeType]) logEnd(contextObject context.Context, found bool, cost float64, err error) {
+	if !o.logger.enabled(contextObject) {
+		return
+	}
+	o.logger.logger.LogAttrs(contextObject, slog.LevelDebug, "astar search finished",
+		slog.String("reason", terminationReason(found, err)),
+		slog.Float64("cost", cost),
+		slog.Int("expanded", o.expandedNodes),
+		slog.Duration("elapsed", time.Since(o.logger.started)),
+	)
+}
+
+// terminationReason names how a search ended for logs.
+func terminationReason(found bool, err error) string {
+	switch {
+	case found:
+		return "found"
+	case errors.Is(err, ErrNoPath):
+		return "no_path"
+	case errors.Is(err, ErrBudgetExceeded):
+		return "budget_exceeded"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "cancelled"
+	default:
+		return "error"
+	}
+}
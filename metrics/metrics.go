@@ -0,0 +1,199 @@
+// Package metrics aggregates the statistics of many astar searches and
+// exposes them in the Prometheus text exposition format.
+//
+// Record every search with Observe and mount the Collector on an HTTP mux:
+//
+//	collector := metrics.NewCollector()
+//	http.Handle("/metrics", collector)
+//
+//	result, err := astar.Search(ctx, graph, start, goal, heuristic)
+//	metrics.Observe(collector, result, err)
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	astar "github.com/pdrpinto/astar"
+)
+
+// Outcome labels used by the astar_searches_total counter.
+const (
+	OutcomeFound          = "found"
+	OutcomeNoPath         = "no_path"
+	OutcomeBudgetExceeded = "budget_exceeded"
+	OutcomeError          = "error"
+)
+
+var (
+	// DurationBuckets are the upper bounds, in seconds, of the latency and
+	// queue wait histograms.
+	DurationBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30}
+	// ExpansionBuckets are the upper bounds of the expanded nodes histogram.
+	ExpansionBuckets = []float64{10, 100, 1_000, 10_000, 100_000, 1_000_000, 10_000_000}
+)
+
+// Collector accumulates counters and histograms across searches. It is safe
+// for concurrent use and implements http.Handler.
+//
+// Every histogram gets one observation per search. In particular the queue
+// wait histogram records the total time the expand tasks of a search waited
+// for a worker, summed over its tasks, not the wait of each task.
+type Collector struct {
+	mutex sync.Mutex
+
+	searches         map[string]uint64
+	expandedNodes    uint64
+	generatedNodes   uint64
+	relaxations      uint64
+	orchestratorTime time.Duration
+	workerTime       time.Duration
+
+	latency    histogram
+	expansions histogram
+	queueWait  histogram
+}
+
+// NewCollector returns an empty Collector.
+func NewCollector() *Collector {
+	return &Collector{
+		searches:   make(map[string]uint64),
+		latency:    newHistogram(DurationBuckets),
+		expansions: newHistogram(ExpansionBuckets),
+		queueWait:  newHistogram(DurationBuckets),
+	}
+}
+
+// Observe records one finished search from its Result and error.
+func Observe[NodeType comparable](collector *Collector, result astar.Result[NodeType], err error) {
+	collector.Record(OutcomeOf(result.Found, err), result.ExpandedNodes, result.Stats)
+}
+
+// OutcomeOf classifies a search for the astar_searches_total counter.
+func OutcomeOf(found bool, err error) string {
+	switch {
+	case found:
+		return OutcomeFound
+	case errors.Is(err, astar.ErrNoPath):
+		return OutcomeNoPath
+	case errors.Is(err, astar.ErrBudgetExceeded):
+		return OutcomeBudgetExceeded
+	default:
+		return OutcomeError
+	}
+}
+
+// Record adds one search with the given outcome label to the collector.
+// Outcomes other than the Outcome constants are written after them, sorted.
+func (collector *Collector) Record(outcome string, expandedNodes int, stats astar.Statistics) {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+
+	collector.searches[outcome]++
+	collector.expandedNodes += uint64(expandedNodes)
+	collector.generatedNodes += uint64(stats.GeneratedNodes)
+	collector.relaxations += uint64(stats.Relaxations)
+	collector.orchestratorTime += stats.OrchestratorTime
+	collector.workerTime += stats.WorkerTime
+
+	collector.latency.observe(stats.WallTime.Seconds())
+	collector.expansions.observe(float64(expandedNodes))
+	collector.queueWait.observe(stats.QueueWait.Seconds())
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (collector *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = collector.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (collector *Collector) WriteTo(w io.Writer) (int64, error) {
+	collector.mutex.Lock()
+	var out strings.Builder
+	writeHeader(&out, "astar_searches_total", "counter", "Searches run, by outcome.")
+	outcomes := []string{OutcomeFound, OutcomeNoPath, OutcomeBudgetExceeded, OutcomeError}
+	var custom []string
+	for outcome := range collector.searches {
+		if !slices.Contains(outcomes, outcome) {
+			custom = append(custom, outcome)
+		}
+	}
+	slices.Sort(custom)
+	for _, outcome := range append(outcomes, custom...) {
+		fmt.Fprintf(&out, "astar_searches_total{outcome=\"%s\"} %d\n", escapeLabel(outcome), collector.searches[outcome])
+	}
+	writeCounter(&out, "astar_expanded_nodes_total", "Nodes expanded across all searches.", float64(collector.expandedNodes))
+	writeCounter(&out, "astar_generated_nodes_total", "Neighbor proposals evaluated across all searches.", float64(collector.generatedNodes))
+	writeCounter(&out, "astar_relaxations_total", "Proposals that improved a g-score across all searches.", float64(collector.relaxations))
+	writeCounter(&out, "astar_orchestrator_seconds_total", "Time orchestrators spent working rather than waiting for workers.", collector.orchestratorTime.Seconds())
+	writeCounter(&out, "astar_worker_seconds_total", "Time workers spent evaluating proposals.", collector.workerTime.Seconds())
+	collector.latency.write(&out, "astar_search_duration_seconds", "Wall time of a search.")
+	collector.expansions.write(&out, "astar_search_expanded_nodes", "Nodes expanded by a search.")
+	collector.queueWait.write(&out, "astar_worker_queue_wait_seconds", "Total time the expand tasks of a search waited for a free worker.")
+	collector.mutex.Unlock()
+
+	written, err := io.WriteString(w, out.String())
+	return int64(written), err
+}
+
+func writeHeader(out *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeCounter(out *strings.Builder, name, help string, value float64) {
+	writeHeader(out, name, "counter", help)
+	fmt.Fprintf(out, "%s %s\n", name, formatFloat(value))
+}
+
+// labelEscaper escapes label values as the exposition format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string { return labelEscaper.Replace(value) }
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// histogram is a cumulative Prometheus histogram.
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) histogram {
+	return histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(value float64) {
+	for i, bound := range h.bounds {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+func (h *histogram) write(out *strings.Builder, name, help string) {
+	writeHeader(out, name, "histogram", help)
+	for i, bound := range h.bounds {
+		fmt.Fprintf(out, "%s_bucket{le=\"%s\"} %d\n", name, formatFloat(bound), h.counts[i])
+	}
+	fmt.Fprintf(out, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(out, "%s_sum %s\n", name, formatFloat(h.sum))
+	fmt.Fprintf(out, "%s_count %d\n", name, h.count)
+}
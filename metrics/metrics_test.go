@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	astar "github.com/pdrpinto/astar"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestCollectorGolden compares the exposition of a few recorded searches,
+// including an outcome label that needs escaping, with testdata.
+func TestCollectorGolden(t *testing.T) {
+	collector := NewCollector()
+	collector.Record(OutcomeFound, 50, astar.Statistics{
+		GeneratedNodes: 180, Relaxations: 70,
+		WallTime: 2 * time.Millisecond, OrchestratorTime: time.Millisecond, WorkerTime: 3 * time.Millisecond,
+		QueueWait: 300 * time.Microsecond,
+	})
+	collector.Record(OutcomeNoPath, 5000, astar.Statistics{
+		GeneratedNodes: 19000, Relaxations: 6000,
+		WallTime: 250 * time.Millisecond, OrchestratorTime: 100 * time.Millisecond, WorkerTime: 400 * time.Millisecond,
+		QueueWait: 20 * time.Millisecond,
+	})
+	collector.Record("cache \"miss\"\\\n", 0, astar.Statistics{})
+
+	var written strings.Builder
+	if _, err := collector.WriteTo(&written); err != nil {
+		t.Fatal(err)
+	}
+	recorder := httptest.NewRecorder()
+	collector.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if served := recorder.Body.String(); served != written.String() {
+		t.Fatalf("ServeHTTP and WriteTo differ:\n%s\n---\n%s", served, written.String())
+	}
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Fatalf("Content-Type %q", contentType)
+	}
+
+	const golden = "testdata/collector.golden"
+	if *update {
+		if err := os.WriteFile(golden, []byte(written.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written.String() != string(want) {
+		t.Fatalf("output differs from %s:\n%s", golden, written.String())
+	}
+}
+
+func TestOutcomeOf(t *testing.T) {
+	tests := []struct {
+		found bool
+		err   error
+		want  string
+	}{
+		{true, nil, OutcomeFound},
+		{false, astar.ErrNoPath, OutcomeNoPath},
+		{false, fmt.Errorf("%w: 10 expansions", astar.ErrBudgetExceeded), OutcomeBudgetExceeded},
+		{false, astar.ErrEngineClosed, OutcomeError},
+		{false, errors.New("tile missing"), OutcomeError},
+		// A partial result never has Found set, but found wins over err.
+		{true, astar.ErrBudgetExceeded, OutcomeFound},
+	}
+	for _, test := range tests {
+		if got := OutcomeOf(test.found, test.err); got != test.want {
+			t.Errorf("OutcomeOf(%v, %v) = %q, want %q", test.found, test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package astar
+
+import "iter"
+
+// MicroEventKind names a single operation inside a node expansion.
+type MicroEventKind int
+
+const (
+	// MicroPop means Node was taken from the open set.
+	MicroPop MicroEventKind = iota
+	// MicroSkipClosed means the popped Node was already closed and is
+	// dropped.
+	MicroSkipClosed
+	// MicroGoal means the popped Node is the goal; the search ends.
+	MicroGoal
+	// MicroExhausted means the open set is empty; the search ends.
+	MicroExhausted
+	// MicroDispatch means an ExpandTask for the edge From -> Node was handed
+	// to a worker.
+	MicroDispatch
+	// MicroReceive means the RelaxProposal for Node came back from a worker.
+	MicroReceive
+	// MicroRelax means the proposal for Node was accepted or rejected, see
+	// Outcome.
+	MicroRelax
+	// MicroPush means Node entered the open set.
+	MicroPush
+	// MicroDecreaseKey means the open Node got a lower f-cost.
+	MicroDecreaseKey
+	// MicroDone is returned by MicroStep once the search is over and all its
+	// events have been delivered.
+	MicroDone
+)
+
+func (kind MicroEventKind) String() string {
+	switch kind {
+	case MicroPop:
+		return "pop"
+	case MicroSkipClosed:
+		return "skip closed"
+	case MicroGoal:
+		return "goal"
+	case MicroExhausted:
+		return "exhausted"
+	case MicroDispatch:
+		return "dispatch"
+	case MicroReceive:
+		return "receive"
+	case MicroRelax:
+		return "relax"
+	case MicroPush:
+		return "push"
+	case MicroDecreaseKey:
+		return "decrease key"
+	case MicroDone:
+		return "done"
+	default:
+		return "unknown"
+	}
+}
+
+// MicroEvent is one operation of the search, as delivered by MicroStep.
+type MicroEvent[NodeType comparable] struct {
+	Kind MicroEventKind
+	// StepIndex is the index of the step the event belongs to, as reported
+	// by the StepSnapshot of that step.
+	StepIndex int
+	// Node is the node the operation applies to: the popped or pushed node,
+	// or the neighbor of a dispatch, receive or relax event.
+	Node NodeType
+	// From is the expanded node of dispatch, receive and relax events.
+	From NodeType
+	// GScore, HScore and FCost are the scores of Node after the operation.
+	// For dispatch events GScore is the g-score of From.
+	GScore float64
+	HScore float64
+	FCost  float64
+	// EdgeCost is the cost of the edge of a dispatch event.
+	EdgeCost      float64
+	NeighborIndex int
+	// Outcome is set on relax events.
+	Outcome RelaxOutcome
+	// PreviousFCost is the f-cost a decrease-key event replaced.
+	PreviousFCost float64
+	// OpenSize is the size of the open set after the operation.
+	OpenSize int
+}
+
+// itemEvent describes an operation on an open set item.
+func (o *orchestrator[NodeType]) itemEvent(kind MicroEventKind, item *PriorityQueueItem[NodeType]) MicroEvent[NodeType] {
+	return MicroEvent[NodeType]{
+		Kind:     kind,
+		Node:     item.Node,
+		GScore:   item.GScore,
+		HScore:   item.FCost - item.GScore,
+		FCost:    item.FCost,
+		OpenSize: o.openSet.Len(),
+	}
+}
+
+// proposalEvent describes an operation on a worker proposal.
+func (o *orchestrator[NodeType]) proposalEvent(kind MicroEventKind, proposal RelaxProposal[NodeType]) MicroEvent[NodeType] {
+	return MicroEvent[NodeType]{
+		Kind:          kind,
+		Node:          proposal.ToNode,
+		From:          proposal.FromNode,
+		GScore:        proposal.GScore,
+		HScore:        proposal.FCost - proposal.GScore,
+		FCost:         proposal.FCost,
+		NeighborIndex: proposal.NeighborIndex,
+		OpenSize:      o.openSet.Len(),
+	}
+}
+
+// MicroStep returns the next operation of the search: a pop, a skipped
+// closed node, a task dispatched to a worker, a proposal received, a
+// relaxation accepted or rejected, a push or a decrease-key. When no event
+// is pending it takes a whole step and then replays its events one per
+// call, so Snapshot shows the state after the step while its events are
+// being delivered. Once the search is over and every event was returned,
+// MicroStep returns an event of kind MicroDone. Step, StepN and RunUntil
+// discard the events of the steps they take.
+func (s *Stepper[NodeType]) MicroStep() (MicroEvent[NodeType], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.orchestrator.micro == nil {
+		s.orchestrator.micro = func(event MicroEvent[NodeType]) {
+			s.microEvents = append(s.microEvents, event)
+		}
+	}
+	for len(s.microEvents) == 0 {
+		if s.done {
+			return MicroEvent[NodeType]{Kind: MicroDone, StepIndex: s.stepCount}, nil
+		}
+		snapshot, err := s.advance()
+		for i := range s.microEvents {
+			s.microEvents[i].StepIndex = s.stepCount
+		}
+		s.complete(&snapshot)
+		s.notify(snapshot)
+		if err != nil {
+			s.microEvents = s.microEvents[:0]
+			return MicroEvent[NodeType]{}, err
+		}
+	}
+	event := s.microEvents[0]
+	s.microEvents = s.microEvents[1:]
+	return event, nil
+}
+
+// MicroSteps returns an iterator over the remaining micro events of the
+// search. Like Steps, it closes the stepper when the loop ends.
+func (s *Stepper[NodeType]) MicroSteps() iter.Seq2[MicroEvent[NodeType], error] {
+	return func(yield func(MicroEvent[NodeType], error) bool) {
+		defer s.Close()
+		for {
+			event, err := s.MicroStep()
+			if event.Kind == MicroDone && err == nil {
+				return
+			}
+			if !yield(event, err) || err != nil {
+				return
+			}
+		}
+	}
+}
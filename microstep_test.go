@@ -0,0 +1,100 @@
+package astar
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// edgeList is a directed graph given by its adjacency lists.
+type edgeList map[int][]Neighbor[int]
+
+func (graph edgeList) Neighbors(node int) []Neighbor[int] { return graph[node] }
+
+func zeroHeuristic(from, to int) float64 { return 0 }
+
+// TestMicroStepOrder checks the exact events of each expansion: a pop, the
+// dispatch and receive of every neighbor, then one relax per neighbor in
+// neighbor order, each followed by the push or decrease-key it causes.
+// It also checks that Step, taken after MicroStep has drained a step, does
+// not replay its events.
+func TestMicroStepOrder(t *testing.T) {
+	graph := edgeList{
+		0: {{ID: 1, Cost: 1}, {ID: 2, Cost: 4}},
+		1: {{ID: 2, Cost: 1}, {ID: 3, Cost: 5}},
+		2: {{ID: 3, Cost: 1}},
+	}
+	stepper := NewStepper(context.Background(), graph, 0, 3, zeroHeuristic, WithWorkers(2), WithDeterministic())
+	defer stepper.Close()
+
+	// takeStep returns the events of the next step, described as
+	// "kind node", with the dispatch and receive events checked and left out
+	// since workers may answer in any order.
+	takeStep := func(wantStep, eventCount int, neighbors ...int) []string {
+		t.Helper()
+		var described []string
+		var dispatched, received []int
+		for range eventCount {
+			event, err := stepper.MicroStep()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.StepIndex != wantStep {
+				t.Fatalf("event %v of node %d belongs to step %d, want step %d", event.Kind, event.Node, event.StepIndex, wantStep)
+			}
+			switch event.Kind {
+			case MicroDispatch:
+				if len(described) == 0 || described[len(described)-1] != fmt.Sprint("pop ", event.From) {
+					t.Fatalf("dispatch of %d after %v", event.Node, described)
+				}
+				dispatched = append(dispatched, event.Node)
+			case MicroReceive:
+				if !slices.Contains(dispatched, event.Node) {
+					t.Fatalf("received %d before dispatching it", event.Node)
+				}
+				received = append(received, event.Node)
+			default:
+				if event.Kind == MicroRelax {
+					described = append(described, fmt.Sprintf("relax %d %v", event.Node, event.Outcome))
+				} else {
+					described = append(described, fmt.Sprint(event.Kind, " ", event.Node))
+				}
+			}
+		}
+		slices.Sort(received)
+		if !slices.Equal(dispatched, neighbors) || !slices.Equal(received, neighbors) {
+			t.Fatalf("step %d dispatched %v and received %v, want %v", wantStep, dispatched, received, neighbors)
+		}
+		return described
+	}
+	check := func(got []string, want ...string) {
+		t.Helper()
+		if !slices.Equal(got, want) {
+			t.Fatalf("got events %q, want %q", got, want)
+		}
+	}
+
+	check(takeStep(1, 10, 1, 2),
+		"push 0", "pop 0",
+		"relax 1 opened", "push 1",
+		"relax 2 opened", "push 2")
+
+	// Step goes on with step 2 instead of replaying the events of step 1.
+	snapshot, err := stepper.Step()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.StepIndex != 2 || snapshot.Current != 1 {
+		t.Fatalf("Step returned step %d expanding %d, want step 2 expanding 1", snapshot.StepIndex, snapshot.Current)
+	}
+
+	check(takeStep(3, 5, 3),
+		"pop 2",
+		"relax 3 improved", "decrease key 3")
+	check(takeStep(4, 2),
+		"pop 3", "goal 3")
+	if event, err := stepper.MicroStep(); err != nil || event.Kind != MicroDone {
+		t.Fatalf("got %v, %v after the goal, want MicroDone", event.Kind, err)
+	}
+}
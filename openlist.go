@@ -0,0 +1,421 @@
+package astar
+
+import (
+	"iter"
+	"math"
+	"math/bits"
+	"slices"
+)
+
+// OpenList is the priority queue that holds the search frontier.
+//
+// Implementations order items by FCost and keep each item's IndexInQueue up
+// to date so that DecreaseKey can find it again.
+type OpenList[NodeType comparable] interface {
+	Len() int
+	Push(item *PriorityQueueItem[NodeType])
+	// Pop removes and returns the item with the lowest FCost.
+	Pop() *PriorityQueueItem[NodeType]
+	// DecreaseKey lowers the scores of an item that is already in the list.
+	DecreaseKey(item *PriorityQueueItem[NodeType], gScore, fCost float64)
+	// All yields every item in the list, in no particular order. The list
+	// must not be modified during the iteration.
+	All() iter.Seq[*PriorityQueueItem[NodeType]]
+}
+
+// OpenListKind selects the OpenList implementation used by a search.
+type OpenListKind int
+
+const (
+	// OpenListBinaryHeap is a binary min-heap. It is the default.
+	OpenListBinaryHeap OpenListKind = iota
+	// OpenListQuaternaryHeap is a 4-ary min-heap: shallower than a binary
+	// heap, which makes decrease-key cheaper on large frontiers.
+	OpenListQuaternaryHeap
+	// OpenListBucketQueue groups items into buckets of BucketWidth f-cost.
+	// It is exact when every f-cost is a multiple of the width, as on grids
+	// with integer costs and heuristics. F-costs must be finite and not
+	// negative.
+	OpenListBucketQueue
+	// OpenListRadixHeap is a monotone radix heap over f-costs. It requires a
+	// consistent heuristic: items pushed below the last popped f-cost are
+	// treated as if they had that f-cost.
+	OpenListRadixHeap
+)
+
+// undoableOpenList is implemented by the open lists that Stepper can take
+// back to an earlier step.
+type undoableOpenList[NodeType comparable] interface {
+	// removeItem takes out an item that is in the list.
+	removeItem(item *PriorityQueueItem[NodeType])
+	// restore puts back an item popped earlier, whose key may be lower than
+	// the keys popped since.
+	restore(item *PriorityQueueItem[NodeType])
+}
+
+// itemLess orders open list items.
+type itemLess[NodeType comparable] func(a, b *PriorityQueueItem[NodeType]) bool
+
+func lessByFCost[NodeType comparable](a, b *PriorityQueueItem[NodeType]) bool {
+	return a.FCost < b.FCost
+}
+
+// newOpenList builds the open list selected by options.
+func newOpenList[NodeType comparable](options Options, less itemLess[NodeType]) OpenList[NodeType] {
+	switch options.OpenList {
+	case OpenListQuaternaryHeap:
+		return &dAryHeap[NodeType]{arity: 4, less: less}
+	case OpenListBucketQueue:
+		width := options.BucketWidth
+		if width <= 0 {
+			width = 1
+		}
+		return &bucketQueue[NodeType]{width: width, less: less, overflow: dAryHeap[NodeType]{arity: 2, less: less}}
+	case OpenListRadixHeap:
+		return &radixHeap[NodeType]{less: less, bucketZero: dAryHeap[NodeType]{arity: 2, less: less}}
+	default:
+		return &dAryHeap[NodeType]{arity: 2, less: less}
+	}
+}
+
+// dAryHeap is an implicit min-heap where every node has arity children.
+type dAryHeap[NodeType comparable] struct {
+	arity int
+	items []*PriorityQueueItem[NodeType]
+	less  itemLess[NodeType]
+}
+
+func (h *dAryHeap[NodeType]) Len() int { return len(h.items) }
+
+func (h *dAryHeap[NodeType]) Push(item *PriorityQueueItem[NodeType]) {
+	item.IndexInQueue = len(h.items)
+	h.items = append(h.items, item)
+	h.up(item.IndexInQueue)
+}
+
+func (h *dAryHeap[NodeType]) Pop() *PriorityQueueItem[NodeType] {
+	return h.remove(0)
+}
+
+func (h *dAryHeap[NodeType]) DecreaseKey(item *PriorityQueueItem[NodeType], gScore, fCost float64) {
+	item.GScore = gScore
+	item.FCost = fCost
+	h.up(item.IndexInQueue)
+}
+
+func (h *dAryHeap[NodeType]) All() iter.Seq[*PriorityQueueItem[NodeType]] {
+	return slices.Values(h.items)
+}
+
+func (h *dAryHeap[NodeType]) removeItem(item *PriorityQueueItem[NodeType]) {
+	h.remove(item.IndexInQueue)
+}
+
+func (h *dAryHeap[NodeType]) restore(item *PriorityQueueItem[NodeType]) { h.Push(item) }
+
+// remove takes out the item at index and restores the heap order.
+func (h *dAryHeap[NodeType]) remove(index int) *PriorityQueueItem[NodeType] {
+	last := len(h.items) - 1
+	item := h.items[index]
+	if index != last {
+		h.swap(index, last)
+	}
+	h.items[last] = nil
+	h.items = h.items[:last]
+	if index != last {
+		h.down(index)
+		h.up(index)
+	}
+	return item
+}
+
+func (h *dAryHeap[NodeType]) swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].IndexInQueue = i
+	h.items[j].IndexInQueue = j
+}
+
+func (h *dAryHeap[NodeType]) up(index int) {
+	for index > 0 {
+		parent := (index - 1) / h.arity
+		if !h.less(h.items[index], h.items[parent]) {
+			return
+		}
+		h.swap(index, parent)
+		index = parent
+	}
+}
+
+func (h *dAryHeap[NodeType]) down(index int) {
+	for {
+		first := index*h.arity + 1
+		if first >= len(h.items) {
+			return
+		}
+		smallest := first
+		for child := first + 1; child < first+h.arity && child < len(h.items); child++ {
+			if h.less(h.items[child], h.items[smallest]) {
+				smallest = child
+			}
+		}
+		if !h.less(h.items[smallest], h.items[index]) {
+			return
+		}
+		h.swap(index, smallest)
+		index = smallest
+	}
+}
+
+// bucketQueue is a monotone bucket queue (Dial's algorithm). Bucket k holds
+// the items whose FCost lies in [k*width, (k+1)*width). Each bucket is a small
+// heap so that items sharing a bucket still come out in order; when they all
+// have the same FCost the heap operations are constant time.
+//
+// Items that would need more than maxBuckets buckets, such as those with an
+// infinite FCost, go to a plain heap instead, so a stray key cannot make the
+// bucket slice huge.
+type bucketQueue[NodeType comparable] struct {
+	width   float64
+	less    itemLess[NodeType]
+	buckets []dAryHeap[NodeType]
+	// offset is the bucket number stored at buckets[0].
+	offset int
+	// cursor is the lowest bucket number that may be non-empty.
+	cursor int
+	// overflow holds the items whose bucket is out of range.
+	overflow dAryHeap[NodeType]
+	size     int
+}
+
+const (
+	// maxBuckets caps the number of buckets a bucketQueue allocates.
+	maxBuckets = 1 << 20
+	// overflowBucket is the bucket number of items held in the overflow heap.
+	overflowBucket = math.MinInt
+)
+
+func (q *bucketQueue[NodeType]) Len() int { return q.size }
+
+// bucketFor returns the bucket number for fCost, or overflowBucket when it
+// falls outside the range the buckets may cover.
+func (q *bucketQueue[NodeType]) bucketFor(fCost float64) int {
+	scaled := math.Floor(fCost / q.width)
+	if !(math.Abs(scaled) < 1<<53) {
+		return overflowBucket
+	}
+	number := int(scaled)
+	if len(q.buckets) > 0 && (number-q.offset >= maxBuckets || q.offset-number+len(q.buckets) > maxBuckets) {
+		return overflowBucket
+	}
+	return number
+}
+
+func (q *bucketQueue[NodeType]) Push(item *PriorityQueueItem[NodeType]) {
+	q.size++
+	number := q.bucketFor(item.FCost)
+	item.bucket = number
+	if number == overflowBucket {
+		q.overflow.Push(item)
+		return
+	}
+	if len(q.buckets) == 0 {
+		q.offset, q.cursor = number, number
+	}
+	if number < q.offset {
+		// Only reachable with an inconsistent heuristic: grow at the front.
+		grown := make([]dAryHeap[NodeType], q.offset-number, q.offset-number+len(q.buckets))
+		q.buckets = append(grown, q.buckets...)
+		q.offset = number
+	}
+	for number-q.offset >= len(q.buckets) {
+		q.buckets = append(q.buckets, dAryHeap[NodeType]{})
+	}
+	bucket := &q.buckets[number-q.offset]
+	if bucket.less == nil {
+		bucket.arity, bucket.less = 2, q.less
+	}
+	bucket.Push(item)
+	if number < q.cursor {
+		q.cursor = number
+	}
+}
+
+func (q *bucketQueue[NodeType]) Pop() *PriorityQueueItem[NodeType] {
+	if q.size == 0 {
+		return nil
+	}
+	bucketed := q.size - q.overflow.Len()
+	q.size--
+	if bucketed == 0 {
+		return q.overflow.Pop()
+	}
+	for q.buckets[q.cursor-q.offset].Len() == 0 {
+		q.cursor++
+	}
+	bucket := &q.buckets[q.cursor-q.offset]
+	if q.overflow.Len() > 0 && q.less(q.overflow.items[0], bucket.items[0]) {
+		return q.overflow.Pop()
+	}
+	return bucket.Pop()
+}
+
+func (q *bucketQueue[NodeType]) DecreaseKey(item *PriorityQueueItem[NodeType], gScore, fCost float64) {
+	q.removeItem(item)
+	item.GScore = gScore
+	item.FCost = fCost
+	q.Push(item)
+}
+
+func (q *bucketQueue[NodeType]) removeItem(item *PriorityQueueItem[NodeType]) {
+	if item.bucket == overflowBucket {
+		q.overflow.remove(item.IndexInQueue)
+	} else {
+		q.buckets[item.bucket-q.offset].remove(item.IndexInQueue)
+	}
+	q.size--
+}
+
+func (q *bucketQueue[NodeType]) restore(item *PriorityQueueItem[NodeType]) { q.Push(item) }
+
+func (q *bucketQueue[NodeType]) All() iter.Seq[*PriorityQueueItem[NodeType]] {
+	return func(yield func(*PriorityQueueItem[NodeType]) bool) {
+		for i := range q.buckets {
+			for _, item := range q.buckets[i].items {
+				if !yield(item) {
+					return
+				}
+			}
+		}
+		for _, item := range q.overflow.items {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// radixHeap is a monotone priority queue over the bit patterns of
+// non-negative float64 keys, which sort like the values themselves. Bucket i
+// holds keys whose highest bit differing from the last popped key is bit
+// i-1, so every item moves down at most 64 times over its lifetime.
+type radixHeap[NodeType comparable] struct {
+	less itemLess[NodeType]
+	last uint64
+	size int
+	// bucketZero holds the items whose key equals last, ordered by less.
+	bucketZero dAryHeap[NodeType]
+	buckets    [64][]*PriorityQueueItem[NodeType]
+}
+
+func (h *radixHeap[NodeType]) Len() int { return h.size }
+
+func (h *radixHeap[NodeType]) key(item *PriorityQueueItem[NodeType]) uint64 {
+	if !(item.FCost > 0) {
+		return 0
+	}
+	return math.Float64bits(item.FCost)
+}
+
+func (h *radixHeap[NodeType]) insert(item *PriorityQueueItem[NodeType], key uint64) {
+	if key < h.last {
+		key = h.last
+	}
+	number := bits.Len64(key ^ h.last)
+	item.bucket = number
+	if number == 0 {
+		h.bucketZero.Push(item)
+		return
+	}
+	item.IndexInQueue = len(h.buckets[number-1])
+	h.buckets[number-1] = append(h.buckets[number-1], item)
+}
+
+func (h *radixHeap[NodeType]) Push(item *PriorityQueueItem[NodeType]) {
+	h.insert(item, h.key(item))
+	h.size++
+}
+
+func (h *radixHeap[NodeType]) Pop() *PriorityQueueItem[NodeType] {
+	if h.size == 0 {
+		return nil
+	}
+	if h.bucketZero.Len() == 0 {
+		number := 0
+		for len(h.buckets[number]) == 0 {
+			number++
+		}
+		bucket := h.buckets[number]
+		h.buckets[number] = bucket[:0]
+		h.last = math.MaxUint64
+		for _, item := range bucket {
+			h.last = min(h.last, h.key(item))
+		}
+		for _, item := range bucket {
+			h.insert(item, h.key(item))
+		}
+		clear(bucket)
+	}
+	h.size--
+	return h.bucketZero.Pop()
+}
+
+func (h *radixHeap[NodeType]) DecreaseKey(item *PriorityQueueItem[NodeType], gScore, fCost float64) {
+	h.detach(item)
+	item.GScore = gScore
+	item.FCost = fCost
+	h.insert(item, h.key(item))
+}
+
+func (h *radixHeap[NodeType]) removeItem(item *PriorityQueueItem[NodeType]) {
+	h.detach(item)
+	h.size--
+}
+
+func (h *radixHeap[NodeType]) restore(item *PriorityQueueItem[NodeType]) {
+	if key := h.key(item); key < h.last {
+		// Lowering last changes the bucket of every item.
+		items := slices.Collect(h.All())
+		clear(h.bucketZero.items)
+		h.bucketZero.items = h.bucketZero.items[:0]
+		for i := range h.buckets {
+			clear(h.buckets[i])
+			h.buckets[i] = h.buckets[i][:0]
+		}
+		h.last = key
+		for _, other := range items {
+			h.insert(other, h.key(other))
+		}
+	}
+	h.Push(item)
+}
+
+// detach takes item out of its bucket without changing the size.
+func (h *radixHeap[NodeType]) detach(item *PriorityQueueItem[NodeType]) {
+	if item.bucket == 0 {
+		h.bucketZero.remove(item.IndexInQueue)
+	} else {
+		bucket := h.buckets[item.bucket-1]
+		last := len(bucket) - 1
+		bucket[item.IndexInQueue] = bucket[last]
+		bucket[item.IndexInQueue].IndexInQueue = item.IndexInQueue
+		bucket[last] = nil
+		h.buckets[item.bucket-1] = bucket[:last]
+	}
+}
+
+func (h *radixHeap[NodeType]) All() iter.Seq[*PriorityQueueItem[NodeType]] {
+	return func(yield func(*PriorityQueueItem[NodeType]) bool) {
+		for _, item := range h.bucketZero.items {
+			if !yield(item) {
+				return
+			}
+		}
+		for _, bucket := range h.buckets {
+			for _, item := range bucket {
+				if !yield(item) {
+					return
+				}
+			}
+		}
+	}
+}
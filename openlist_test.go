@@ -0,0 +1,177 @@
+package astar
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+var openListKinds = []struct {
+	name string
+	kind OpenListKind
+}{
+	{"binary heap", OpenListBinaryHeap},
+	{"quaternary heap", OpenListQuaternaryHeap},
+	{"bucket queue", OpenListBucketQueue},
+	{"radix heap", OpenListRadixHeap},
+}
+
+// referenceMin returns the lowest live item by a linear scan.
+func referenceMin(live map[*PriorityQueueItem[int]]bool, less itemLess[int]) *PriorityQueueItem[int] {
+	var best *PriorityQueueItem[int]
+	for item := range live {
+		if best == nil || less(item, best) {
+			best = item
+		}
+	}
+	return best
+}
+
+// TestOpenListsMatchReference runs random push, pop, decrease-key and
+// remove sequences on every open list and compares each pop with a linear
+// scan. Keys never go below the last popped f-cost, which the monotone
+// lists require, and are integers so the bucket queue is exact. Some keys
+// are infinite or too far ahead for the bucket queue to keep in buckets.
+func TestOpenListsMatchReference(t *testing.T) {
+	for _, test := range openListKinds {
+		t.Run(test.name, func(t *testing.T) {
+			for seed := int64(0); seed < 50; seed++ {
+				rng := rand.New(rand.NewSource(seed))
+				options := applyOptions(defaultOptions(), []Option{WithOpenList(test.kind), WithTieBreak(TieBreakFIFO)})
+				less := newItemLess[int](options)
+				list := newOpenList(options, less)
+				live := map[*PriorityQueueItem[int]]bool{}
+				var items []*PriorityQueueItem[int]
+				lastPopped := 0.0
+				sequence := uint64(0)
+				for operation := 0; operation < 2000; operation++ {
+					switch choice := rng.Intn(10); {
+					case choice < 4 || len(live) == 0:
+						f := lastPopped + float64(rng.Intn(20))
+						switch rng.Intn(10) {
+						case 0:
+							f = math.Inf(1)
+						case 1:
+							f += 1e9
+						}
+						item := &PriorityQueueItem[int]{Node: operation, GScore: f / 2, FCost: f, sequence: sequence}
+						sequence++
+						list.Push(item)
+						live[item] = true
+						items = append(items, item)
+					case choice < 7:
+						want := referenceMin(live, less)
+						got := list.Pop()
+						if got != want {
+							t.Fatalf("seed %d op %d: popped node %d (f=%g), want node %d (f=%g)",
+								seed, operation, got.Node, got.FCost, want.Node, want.FCost)
+						}
+						delete(live, got)
+						lastPopped = got.FCost
+					case choice < 9:
+						item := items[rng.Intn(len(items))]
+						if !live[item] || item.FCost <= lastPopped {
+							continue
+						}
+						f := lastPopped + float64(rng.Intn(int(min(item.FCost-lastPopped, 1e6))))
+						list.DecreaseKey(item, f/2, f)
+					default:
+						item := items[rng.Intn(len(items))]
+						if !live[item] {
+							continue
+						}
+						list.(undoableOpenList[int]).removeItem(item)
+						delete(live, item)
+					}
+					if list.Len() != len(live) {
+						t.Fatalf("seed %d op %d: Len() = %d, want %d", seed, operation, list.Len(), len(live))
+					}
+				}
+				seen := 0
+				for item := range list.All() {
+					if !live[item] {
+						t.Fatalf("seed %d: All() yielded node %d, which is not in the list", seed, item.Node)
+					}
+					seen++
+				}
+				if seen != len(live) {
+					t.Fatalf("seed %d: All() yielded %d items, want %d", seed, seen, len(live))
+				}
+			}
+		})
+	}
+}
+
+// TestOpenListsRestore checks that items restored below the last popped
+// f-cost, as StepBack does, come out first again.
+func TestOpenListsRestore(t *testing.T) {
+	for _, test := range openListKinds {
+		t.Run(test.name, func(t *testing.T) {
+			options := applyOptions(defaultOptions(), []Option{WithOpenList(test.kind), WithTieBreak(TieBreakFIFO)})
+			list := newOpenList(options, newItemLess[int](options))
+			var popped []*PriorityQueueItem[int]
+			for i := range 10 {
+				list.Push(&PriorityQueueItem[int]{Node: i, FCost: float64(i), sequence: uint64(i)})
+			}
+			for range 6 {
+				popped = append(popped, list.Pop())
+			}
+			for i := len(popped) - 1; i >= 0; i-- {
+				list.(undoableOpenList[int]).restore(popped[i])
+			}
+			for want := range 10 {
+				if got := list.Pop(); got.Node != want {
+					t.Fatalf("pop %d returned node %d", want, got.Node)
+				}
+			}
+		})
+	}
+}
+
+// TestOpenListsInfiniteHeuristic runs searches whose heuristic returns +Inf
+// for dead ends with every open list, through Search and Start, and checks
+// that they find the same cost as the binary heap.
+func TestOpenListsInfiniteHeuristic(t *testing.T) {
+	grid := newTestGrid(15, 15, 0.2, 6)
+	start, goal := testPoint{0, 0}, testPoint{14, 14}
+	want, err := Search(context.Background(), grid, start, goal, manhattan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range openListKinds {
+		t.Run(test.name, func(t *testing.T) {
+			options := []Option{WithWorkers(2), WithOpenList(test.kind)}
+			result, err := Search(context.Background(), grid, start, goal, deadEndHeuristic, options...)
+			if err != nil || result.TotalCost != want.TotalCost {
+				t.Fatalf("Search: cost %g, error %v; want cost %g", result.TotalCost, err, want.TotalCost)
+			}
+			result, err = Start(context.Background(), grid, start, goal, deadEndHeuristic, options...).Wait()
+			if err != nil || result.TotalCost != want.TotalCost {
+				t.Fatalf("Start: cost %g, error %v; want cost %g", result.TotalCost, err, want.TotalCost)
+			}
+		})
+	}
+}
+
+// TestSearchRejectsInvalidHeuristic checks that negative and NaN estimates
+// stop the search with ErrInvalidHeuristic on every open list.
+func TestSearchRejectsInvalidHeuristic(t *testing.T) {
+	grid := newTestGrid(10, 10, 0, 1)
+	start, goal := testPoint{0, 0}, testPoint{9, 9}
+	for _, estimate := range []float64{-1, math.NaN()} {
+		heuristic := func(from, to testPoint) float64 {
+			if from == (testPoint{1, 0}) {
+				return estimate
+			}
+			return manhattan(from, to)
+		}
+		for _, test := range openListKinds {
+			_, err := Search(context.Background(), grid, start, goal, heuristic, WithOpenList(test.kind))
+			if !errors.Is(err, ErrInvalidHeuristic) {
+				t.Errorf("%s with h=%g: error %v, want ErrInvalidHeuristic", test.name, estimate, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package astar
+
+import (
+	"context"
+	"time"
+)
+
+// expansionOutcome tells the driver of an orchestrator what an expansion did.
+type expansionOutcome int
+
+const (
+	// expansionContinue means a node was expanded and the search goes on.
+	expansionContinue expansionOutcome = iota
+	// expansionFound means the goal was popped from the open set.
+	expansionFound
+	// expansionExhausted means the open set is empty.
+	expansionExhausted
+)
+
+// orchestrator owns the frontier of one search. Search drives it until the
+// goal is found, Stepper drives it one expansion at a time. Neighbor
+// relaxations are computed by a worker pool, which may be shared with other
+// searches; proposals come back on the orchestrator's own channel.
+type orchestrator[NodeType comparable] struct {
+	graph     Graph[NodeType]
+	state     nodeState[NodeType]
+	openSet   OpenList[NodeType]
+	less      itemLess[NodeType]
+	startNode NodeType
+	goalNode  NodeType
+	heuristic Heuristic[NodeType]
+
+	pool                 *workerPool[NodeType]
+	relaxProposalChannel chan RelaxProposal[NodeType]
+
+	budget   budget
+	tracer   Tracer[NodeType]
+	logger   *searchLogger
+	progress *progressReporter
+	// journal records changes for Stepper.StepBack; nil in other searches.
+	journal *undoLog[NodeType]
+	// blocked holds the obstacles added by Stepper.AddObstacle.
+	blocked map[NodeType]bool
+	// micro receives the micro events of Stepper.MicroStep; nil otherwise.
+	micro func(MicroEvent[NodeType])
+	// deterministic buffers proposals so they are applied in neighbor order.
+	deterministic bool
+	proposals     []RelaxProposal[NodeType]
+
+	expandedNodes int
+	// lastPoppedF is the f-cost of the last expanded node, the best f known
+	// to be reachable.
+	lastPoppedF  float64
+	nextSequence uint64
+	stats        Statistics
+}
+
+func newOrchestrator[NodeType comparable](
+	graph Graph[NodeType],
+	state nodeState[NodeType],
+	pool *workerPool[NodeType],
+	startNode NodeType,
+	goalNode NodeType,
+	heuristic Heuristic[NodeType],
+	searchOptions Options,
+) *orchestrator[NodeType] {
+	less := newItemLess[NodeType](searchOptions)
+	o := &orchestrator[NodeType]{
+		graph:                graph,
+		state:                state,
+		openSet:              newOpenList(searchOptions, less),
+		less:                 less,
+		startNode:            startNode,
+		goalNode:             goalNode,
+		heuristic:            heuristic,
+		budget:               newBudget(searchOptions),
+		tracer:               tracersFor[NodeType](searchOptions),
+		logger:               newSearchLogger(searchOptions),
+		progress:             newProgressReporter(searchOptions),
+		deterministic:        searchOptions.Deterministic,
+		pool:                 pool,
+		relaxProposalChannel: make(chan RelaxProposal[NodeType]),
+	}
+	return o
+}
+
+// begin puts the start node in the open set.
+func (o *orchestrator[NodeType]) begin() error {
+	if !o.state.valid(o.startNode) {
+		return ErrInvalidStart
+	}
+	if !o.state.valid(o.goalNode) {
+		return ErrInvalidGoal
+	}
+	h, err := safeHeuristic(o.heuristic, o.startNode, o.goalNode)
+	if err != nil {
+		return err
+	}
+	if o.progress != nil {
+		o.progress.start(h)
+	}
+	o.journal.nodeChanging(o.startNode)
+	o.state.setGScore(o.startNode, 0.0)
+	o.push(o.newItem(o.startNode, 0.0, h))
+	return nil
+}
+
+// expandNext pops the best open node, skipping closed ones, and relaxes its
+// neighbors. It returns the popped item, or nil when the open set is empty.
+func (o *orchestrator[NodeType]) expandNext(contextObject context.Context) (*PriorityQueueItem[NodeType], expansionOutcome, error) {
+	expansionStart := time.Now()
+	var waiting time.Duration
+	defer func() { o.stats.OrchestratorTime += time.Since(expansionStart) - waiting }()
+
+	if err := o.budget.checkBefore(o.expandedNodes); err != nil {
+		return nil, expansionContinue, err
+	}
+	select {
+	case <-o.pool.quit:
+		return nil, expansionContinue, ErrEngineClosed
+	default:
+	}
+	var currentItem *PriorityQueueItem[NodeType]
+	for {
+		if o.openSet.Len() == 0 {
+			if o.micro != nil {
+				o.micro(MicroEvent[NodeType]{Kind: MicroExhausted})
+			}
+			return nil, expansionExhausted, nil
+		}
+		currentItem = o.openSet.Pop()
+		if o.micro != nil {
+			o.micro(o.itemEvent(MicroPop, currentItem))
+		}
+		// Skip if already closed
+		if o.state.isClosed(currentItem.Node) {
+			o.state.removeOpenItem(currentItem.Node)
+			if o.tracer != nil {
+				o.tracer.OnSkipClosed(currentItem.Node)
+			}
+			if o.micro != nil {
+				o.micro(o.itemEvent(MicroSkipClosed, currentItem))
+			}
+			continue
+		}
+		if err := o.budget.checkCost(currentItem.FCost); err != nil {
+			// Leave the node open so it counts towards the partial result.
+			o.openSet.Push(currentItem)
+			return nil, expansionContinue, err
+		}
+		o.state.removeOpenItem(currentItem.Node)
+		break
+	}
+	currentNode := currentItem.Node
+	o.state.setClosed(currentNode)
+	o.journal.popped(currentItem)
+	o.expandedNodes++
+	o.lastPoppedF = currentItem.FCost
+	if o.progress != nil {
+		o.progress.bestH = min(o.progress.bestH, currentItem.FCost-currentItem.GScore)
+	}
+	if (o.logger != nil || o.progress != nil) && o.expandedNodes%progressCheckEvery == 0 {
+		now := time.Now()
+		if o.logger != nil {
+			o.logProgress(contextObject, now)
+		}
+		if o.progress != nil {
+			o.reportProgress(now)
+		}
+	}
+	if o.tracer != nil {
+		o.tracer.OnPop(currentNode, currentItem.GScore, currentItem.FCost)
+	}
+
+	// Goal check
+	if currentNode == o.goalNode {
+		if o.tracer != nil {
+			o.tracer.OnGoal(currentNode, currentItem.GScore)
+		}
+		if o.micro != nil {
+			o.micro(o.itemEvent(MicroGoal, currentItem))
+		}
+		return currentItem, expansionFound, nil
+	}
+
+	// Send tasks to workers for each neighbor and collect their proposals.
+	// Sending and receiving are interleaved so that a node with more
+	// neighbors than workers cannot deadlock the pool.
+	neighbors, err := neighborsOf(contextObject, o.graph, currentNode)
+	if err != nil {
+		return currentItem, expansionContinue, err
+	}
+	if o.deterministic {
+		o.proposals = append(o.proposals[:0], make([]RelaxProposal[NodeType], len(neighbors))...)
+	}
+	sent, received := 0, 0
+	var task ExpandTask[NodeType]
+	for received < len(neighbors) {
+		var taskChannel chan ExpandTask[NodeType]
+		waitStart := time.Now()
+		if sent < len(neighbors) {
+			taskChannel = o.pool.expandTaskChannel
+			if task.dispatched.IsZero() {
+				task = ExpandTask[NodeType]{
+					FromNode:      currentNode,
+					Neighbor:      neighbors[sent],
+					CurrentGScore: currentItem.GScore,
+					GoalNode:      o.goalNode,
+					HeuristicFunc: o.heuristic,
+					NeighborIndex: sent,
+					replyChannel:  o.relaxProposalChannel,
+					cancelled:     contextObject.Done(),
+					dispatched:    waitStart,
+				}
+			}
+		}
+		select {
+		case <-contextObject.Done():
+			return currentItem, expansionContinue, contextObject.Err()
+		case <-o.pool.quit:
+			return currentItem, expansionContinue, ErrEngineClosed
+		case taskChannel <- task:
+			sent++
+			if o.micro != nil {
+				o.micro(MicroEvent[NodeType]{
+					Kind:          MicroDispatch,
+					Node:          task.Neighbor.ID,
+					From:          currentNode,
+					GScore:        task.CurrentGScore,
+					EdgeCost:      task.Neighbor.Cost,
+					NeighborIndex: task.NeighborIndex,
+					OpenSize:      o.openSet.Len(),
+				})
+			}
+			task = ExpandTask[NodeType]{}
+		case proposal := <-o.relaxProposalChannel:
+			received++
+			o.stats.GeneratedNodes++
+			o.stats.WorkerTime += proposal.duration
+			o.stats.QueueWait += proposal.queueWait
+			if proposal.err != nil {
+				return currentItem, expansionContinue, proposal.err
+			}
+			if o.micro != nil {
+				o.micro(o.proposalEvent(MicroReceive, proposal))
+			}
+			if o.deterministic {
+				o.proposals[proposal.NeighborIndex] = proposal
+			} else {
+				o.relax(proposal)
+			}
+		}
+		waiting += time.Since(waitStart)
+	}
+	if o.deterministic {
+		for _, proposal := range o.proposals {
+			o.relax(proposal)
+		}
+	}
+	return currentItem, expansionContinue, nil
+}
+
+// relax applies a worker proposal if it improves the known path to its node.
+func (o *orchestrator[NodeType]) relax(proposal RelaxProposal[NodeType]) {
+	currentG, exists := o.state.gScore(proposal.ToNode)
+	outcome := o.applyRelax(proposal, currentG, exists)
+	if o.tracer != nil {
+		o.tracer.OnRelax(RelaxEvent[NodeType]{
+			From:           proposal.FromNode,
+			To:             proposal.ToNode,
+			GScore:         proposal.GScore,
+			HScore:         proposal.FCost - proposal.GScore,
+			FCost:          proposal.FCost,
+			Outcome:        outcome,
+			PreviousGScore: currentG,
+			HadGScore:      exists,
+		})
+	}
+}
+
+func (o *orchestrator[NodeType]) applyRelax(proposal RelaxProposal[NodeType], currentG float64, exists bool) RelaxOutcome {
+	if o.blocked[proposal.ToNode] {
+		return o.decided(proposal, RelaxRejectedBlocked)
+	}
+	if o.state.isClosed(proposal.ToNode) {
+		if proposal.GScore < currentG {
+			o.stats.Reopenings++
+		}
+		return o.decided(proposal, RelaxRejectedClosed)
+	}
+	if exists && proposal.GScore >= currentG {
+		return o.decided(proposal, RelaxRejectedNotBetter)
+	}
+	o.stats.Relaxations++
+	o.journal.nodeChanging(proposal.ToNode)
+	o.state.setGScore(proposal.ToNode, proposal.GScore)
+	o.state.setParent(proposal.ToNode, proposal.FromNode)
+	item, inOpen := o.state.openItem(proposal.ToNode)
+	if !inOpen {
+		o.decided(proposal, RelaxOpened)
+		o.push(o.newItem(proposal.ToNode, proposal.GScore, proposal.FCost))
+		return RelaxOpened
+	}
+	o.decided(proposal, RelaxImproved)
+	if proposal.FCost < item.FCost {
+		o.stats.DecreaseKeys++
+		o.journal.decreasing(item)
+		previousFCost := item.FCost
+		o.openSet.DecreaseKey(item, proposal.GScore, proposal.FCost)
+		if o.micro != nil {
+			event := o.itemEvent(MicroDecreaseKey, item)
+			event.PreviousFCost = previousFCost
+			o.micro(event)
+		}
+	}
+	return RelaxImproved
+}
+
+// decided reports the outcome of a relaxation to the micro event sink,
+// before the open set changes.
+func (o *orchestrator[NodeType]) decided(proposal RelaxProposal[NodeType], outcome RelaxOutcome) RelaxOutcome {
+	if o.micro != nil {
+		event := o.proposalEvent(MicroRelax, proposal)
+		event.Outcome = outcome
+		o.micro(event)
+	}
+	return outcome
+}
+
+// push adds a new item to the open set.
+func (o *orchestrator[NodeType]) push(item *PriorityQueueItem[NodeType]) {
+	o.openSet.Push(item)
+	o.state.setOpenItem(item.Node, item)
+	o.journal.pushed(item)
+	o.stats.MaxFrontierSize = max(o.stats.MaxFrontierSize, o.openSet.Len())
+	if o.tracer != nil {
+		o.tracer.OnPush(item.Node, item.GScore, item.FCost)
+	}
+	if o.micro != nil {
+		o.micro(o.itemEvent(MicroPush, item))
+	}
+}
+
+// newItem creates an open set item stamped with the next insertion sequence.
+func (o *orchestrator[NodeType]) newItem(node NodeType, gScore, fCost float64) *PriorityQueueItem[NodeType] {
+	item := &PriorityQueueItem[NodeType]{
+		Node:     node,
+		GScore:   gScore,
+		FCost:    fCost,
+		sequence: o.nextSequence,
+	}
+	o.nextSequence++
+	return item
+}
+
+// path rebuilds the path from the start node to node.
+func (o *orchestrator[NodeType]) path(node NodeType) []NodeType {
+	return reconstructStatePath(o.state, node, o.startNode)
+}
@@ -1,12 +1,21 @@
 package astar
 
+// PriorityQueueItem is an entry of the open set.
 type PriorityQueueItem[NodeType comparable] struct {
 	Node         NodeType
 	GScore       float64
 	FCost        float64
 	IndexInQueue int
+
+	// bucket is the bucket holding the item in bucketed open lists.
+	bucket int
+	// sequence is the insertion order of the item, used for tie-breaking.
+	sequence uint64
 }
 
+// PriorityQueue is a binary min-heap ordered by FCost for use with
+// container/heap. Searches use the OpenList implementations selected by
+// WithOpenList instead.
 type PriorityQueue[NodeType comparable] []*PriorityQueueItem[NodeType]
 
 func (queue PriorityQueue[NodeType]) Len() int           { return len(queue) }
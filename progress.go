@@ -0,0 +1,136 @@
+package astar
+
+import (
+	"math"
+	"time"
+)
+
+// defaultProgressInterval is used when WithProgress is given no interval.
+const defaultProgressInterval = time.Second
+
+// progressCheckEvery is how many expansions pass between two clock reads
+// for progress records and reports.
+const progressCheckEvery = 64
+
+// Progress describes a running search.
+type Progress struct {
+	Expanded int
+	OpenSize int
+	// MinF is the lowest f-cost in the open set. With an admissible
+	// heuristic it is a lower bound on the cost of the optimal path.
+	MinF float64
+	// ExpansionsPerSecond is the average rate since the search started.
+	ExpansionsPerSecond float64
+	// Fraction estimates how much of the search is done, from 0 to 1. It is
+	// derived from the lowest heuristic value among expanded nodes relative
+	// to the heuristic value of the start node, so it is only as good as the
+	// heuristic and can stall in front of obstacles.
+	Fraction float64
+	Elapsed  time.Duration
+	// Done is set on the last report, sent when the search ends.
+	Done bool
+}
+
+// WithProgress calls report with the state of the search at most once per
+// interval, and once more when the search ends. report runs on the search
+// goroutine and should return quickly; send to a buffered channel from it to
+// consume reports elsewhere.
+func WithProgress(interval time.Duration, report func(Progress)) Option {
+	return func(options *Options) {
+		options.ProgressInterval = interval
+		options.Progress = report
+	}
+}
+
+// progressReporter computes the Progress reports of one search.
+type progressReporter struct {
+	report     func(Progress)
+	interval   time.Duration
+	started    time.Time
+	lastReport time.Time
+	// startH is the heuristic value of the start node, bestH the lowest
+	// heuristic value of an expanded node.
+	startH float64
+	bestH  float64
+}
+
+func newProgressReporter(searchOptions Options) *progressReporter {
+	if searchOptions.Progress == nil {
+		return nil
+	}
+	interval := searchOptions.ProgressInterval
+	if interval <= 0 {
+		interval = defaultProgressInterval
+	}
+	return &progressReporter{report: searchOptions.Progress, interval: interval}
+}
+
+// start records the beginning of the search.
+func (p *progressReporter) start(startH float64) {
+	p.started = time.Now()
+	p.lastReport = p.started
+	p.startH = startH
+	p.bestH = startH
+}
+
+// fraction estimates the share of the search that is done.
+func (p *progressReporter) fraction() float64 {
+	if !(p.startH > 0) || math.IsInf(p.startH, 1) {
+		return 0
+	}
+	return min(max(1-p.bestH/p.startH, 0), 1)
+}
+
+// reportProgress sends a report if the progress interval elapsed.
+func (o *orchestrator[NodeType]) reportProgress(now time.Time) {
+	if now.Sub(o.progress.lastReport) < o.progress.interval {
+		return
+	}
+	o.progress.lastReport = now
+	o.progress.report(o.currentProgress(now))
+}
+
+// reportDone sends the final report of the search.
+func (o *orchestrator[NodeType]) reportDone(found bool, cost float64) {
+	if o.progress == nil || o.progress.started.IsZero() {
+		return
+	}
+	progress := o.currentProgress(time.Now())
+	progress.Done = true
+	if found {
+		progress.MinF = cost
+		progress.Fraction = 1
+	}
+	o.progress.report(progress)
+}
+
+func (o *orchestrator[NodeType]) currentProgress(now time.Time) Progress {
+	elapsed := now.Sub(o.progress.started)
+	progress := Progress{
+		Expanded: o.expandedNodes,
+		OpenSize: o.openSet.Len(),
+		MinF:     o.minOpenF(),
+		Fraction: o.progress.fraction(),
+		Elapsed:  elapsed,
+	}
+	if elapsed > 0 {
+		progress.ExpansionsPerSecond = float64(o.expandedNodes) / elapsed.Seconds()
+	}
+	return progress
+}
+
+// minOpenF returns the lowest f-cost in the open set, or the f-cost of the
+// last expanded node when the open set is empty.
+func (o *orchestrator[NodeType]) minOpenF() float64 {
+	if o.openSet.Len() == 0 {
+		return o.lastPoppedF
+	}
+	minF := math.Inf(1)
+	for item := range o.openSet.All() {
+		// Closed nodes still waiting in the open list are stale.
+		if !o.state.isClosed(item.Node) {
+			minF = min(minF, item.FCost)
+		}
+	}
+	return minF
+}
@@ -0,0 +1,217 @@
+package astar
+
+// Indexer is an optional interface a Graph can implement when its nodes map
+// onto dense integer IDs. Index must return a value in [0, Len()) for every
+// node the search can reach.
+//
+// SearchIndexed and Engine replace the per-search maps of such graphs with
+// preallocated slices, which avoids map hashing and growth on large graphs.
+// The slices cover every node, so they pay off only when they are reused:
+// plain Search keeps using maps.
+type Indexer[NodeType comparable] interface {
+	Index(node NodeType) int
+	Len() int
+}
+
+// IndexedGraph is a Graph whose nodes can be indexed densely.
+type IndexedGraph[NodeType comparable] interface {
+	Graph[NodeType]
+	Indexer[NodeType]
+}
+
+// nodeState stores the per-node bookkeeping of a search: g-scores,
+// predecessors, the closed set and the open set items.
+type nodeState[NodeType comparable] interface {
+	// valid reports whether the state can hold node.
+	valid(node NodeType) bool
+	gScore(node NodeType) (float64, bool)
+	setGScore(node NodeType, g float64)
+	parent(node NodeType) (NodeType, bool)
+	setParent(node NodeType, parent NodeType)
+	isClosed(node NodeType) bool
+	setClosed(node NodeType)
+	openItem(node NodeType) (*PriorityQueueItem[NodeType], bool)
+	setOpenItem(node NodeType, item *PriorityQueueItem[NodeType])
+	removeOpenItem(node NodeType)
+}
+
+// mapState is the default nodeState, backed by maps keyed by node.
+type mapState[NodeType comparable] struct {
+	cameFrom   map[NodeType]NodeType
+	gScores    map[NodeType]float64
+	closedSet  map[NodeType]bool
+	openSetMap map[NodeType]*PriorityQueueItem[NodeType]
+}
+
+func newMapState[NodeType comparable]() *mapState[NodeType] {
+	return &mapState[NodeType]{
+		cameFrom:   make(map[NodeType]NodeType),
+		gScores:    make(map[NodeType]float64),
+		closedSet:  make(map[NodeType]bool),
+		openSetMap: make(map[NodeType]*PriorityQueueItem[NodeType]),
+	}
+}
+
+func (state *mapState[NodeType]) valid(node NodeType) bool { return true }
+
+func (state *mapState[NodeType]) gScore(node NodeType) (float64, bool) {
+	g, exists := state.gScores[node]
+	return g, exists
+}
+
+func (state *mapState[NodeType]) setGScore(node NodeType, g float64) { state.gScores[node] = g }
+
+func (state *mapState[NodeType]) parent(node NodeType) (NodeType, bool) {
+	previousNode, exists := state.cameFrom[node]
+	return previousNode, exists
+}
+
+func (state *mapState[NodeType]) setParent(node NodeType, parent NodeType) {
+	state.cameFrom[node] = parent
+}
+
+func (state *mapState[NodeType]) isClosed(node NodeType) bool { return state.closedSet[node] }
+func (state *mapState[NodeType]) setClosed(node NodeType)     { state.closedSet[node] = true }
+
+func (state *mapState[NodeType]) openItem(node NodeType) (*PriorityQueueItem[NodeType], bool) {
+	item, inOpen := state.openSetMap[node]
+	return item, inOpen
+}
+
+func (state *mapState[NodeType]) setOpenItem(node NodeType, item *PriorityQueueItem[NodeType]) {
+	state.openSetMap[node] = item
+}
+
+func (state *mapState[NodeType]) removeOpenItem(node NodeType) { delete(state.openSetMap, node) }
+
+// reset empties the maps while keeping their allocated space.
+func (state *mapState[NodeType]) reset() {
+	clear(state.cameFrom)
+	clear(state.gScores)
+	clear(state.closedSet)
+	clear(state.openSetMap)
+}
+
+// IndexedState is slice-backed search state for graphs that implement Indexer.
+//
+// A state can be reused across searches with SearchIndexed. Instead of
+// clearing its slices, every search bumps a generation counter and entries
+// stamped with an older generation are treated as unset. An IndexedState must
+// not be used by two searches at the same time.
+type IndexedState[NodeType comparable] struct {
+	indexer    Indexer[NodeType]
+	generation uint32
+	// stamps[i] == generation means node i was reached in the current search,
+	// stamps[i] == generation+1 means it was also closed.
+	stamps    []uint32
+	hasParent []uint32
+	gScores   []float64
+	cameFrom  []NodeType
+	openItems []*PriorityQueueItem[NodeType]
+}
+
+// NewIndexedState allocates state for graphs with up to capacity nodes. The
+// slices grow on demand if a later search uses a larger graph.
+func NewIndexedState[NodeType comparable](capacity int) *IndexedState[NodeType] {
+	state := &IndexedState[NodeType]{}
+	state.grow(capacity)
+	return state
+}
+
+func (state *IndexedState[NodeType]) grow(size int) {
+	if size <= len(state.stamps) {
+		return
+	}
+	state.stamps = append(state.stamps, make([]uint32, size-len(state.stamps))...)
+	state.hasParent = append(state.hasParent, make([]uint32, size-len(state.hasParent))...)
+	state.gScores = append(state.gScores, make([]float64, size-len(state.gScores))...)
+	state.cameFrom = append(state.cameFrom, make([]NodeType, size-len(state.cameFrom))...)
+	state.openItems = append(state.openItems, make([]*PriorityQueueItem[NodeType], size-len(state.openItems))...)
+}
+
+// begin prepares the state for a new search over indexer.
+func (state *IndexedState[NodeType]) begin(indexer Indexer[NodeType]) {
+	state.indexer = indexer
+	state.grow(indexer.Len())
+	// Two stamp values are used per generation (reached and closed).
+	state.generation += 2
+	if state.generation < 2 {
+		// The counter wrapped: old stamps could collide, so clear once.
+		clear(state.stamps)
+		clear(state.hasParent)
+		clear(state.openItems)
+		state.generation = 2
+	}
+}
+
+func (state *IndexedState[NodeType]) valid(node NodeType) bool {
+	index := state.indexer.Index(node)
+	return index >= 0 && index < state.indexer.Len()
+}
+
+func (state *IndexedState[NodeType]) reached(index int) bool {
+	return state.stamps[index] >= state.generation
+}
+
+func (state *IndexedState[NodeType]) gScore(node NodeType) (float64, bool) {
+	index := state.indexer.Index(node)
+	if !state.reached(index) {
+		return 0, false
+	}
+	return state.gScores[index], true
+}
+
+func (state *IndexedState[NodeType]) setGScore(node NodeType, g float64) {
+	index := state.indexer.Index(node)
+	if !state.reached(index) {
+		state.stamps[index] = state.generation
+		state.openItems[index] = nil
+	}
+	state.gScores[index] = g
+}
+
+func (state *IndexedState[NodeType]) parent(node NodeType) (NodeType, bool) {
+	index := state.indexer.Index(node)
+	if state.hasParent[index] != state.generation {
+		var zero NodeType
+		return zero, false
+	}
+	return state.cameFrom[index], true
+}
+
+func (state *IndexedState[NodeType]) setParent(node NodeType, parent NodeType) {
+	index := state.indexer.Index(node)
+	state.cameFrom[index] = parent
+	state.hasParent[index] = state.generation
+}
+
+func (state *IndexedState[NodeType]) isClosed(node NodeType) bool {
+	return state.stamps[state.indexer.Index(node)] == state.generation+1
+}
+
+func (state *IndexedState[NodeType]) setClosed(node NodeType) {
+	state.stamps[state.indexer.Index(node)] = state.generation + 1
+}
+
+func (state *IndexedState[NodeType]) openItem(node NodeType) (*PriorityQueueItem[NodeType], bool) {
+	index := state.indexer.Index(node)
+	if !state.reached(index) || state.openItems[index] == nil {
+		return nil, false
+	}
+	return state.openItems[index], true
+}
+
+func (state *IndexedState[NodeType]) setOpenItem(node NodeType, item *PriorityQueueItem[NodeType]) {
+	index := state.indexer.Index(node)
+	if !state.reached(index) {
+		state.stamps[index] = state.generation
+	}
+	state.openItems[index] = item
+}
+
+func (state *IndexedState[NodeType]) removeOpenItem(node NodeType) {
+	index := state.indexer.Index(node)
+	if state.reached(index) {
+		state.openItems[index] = nil
+	}
+}
@@ -0,0 +1,43 @@
+package astar
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// TestIndexedStateReuse runs many queries on one IndexedState, across a
+// wraparound of its generation counter, and compares each with a search on
+// fresh map state.
+func TestIndexedStateReuse(t *testing.T) {
+	grid := newTestGrid(25, 25, 0.3, 7)
+	state := NewIndexedState[testPoint](grid.Len())
+	rng := rand.New(rand.NewSource(7))
+	options := []Option{WithWorkers(2), WithDeterministic(), WithTieBreak(TieBreakFIFO)}
+	wrapped := false
+	for query := range 200 {
+		if query == 100 {
+			// Three queries before the counter wraps.
+			state.generation = math.MaxUint32 - 5
+		}
+		start := testPoint{rng.Intn(25), rng.Intn(25)}
+		goal := testPoint{rng.Intn(25), rng.Intn(25)}
+		if grid.walls[start] || grid.walls[goal] {
+			continue
+		}
+		got, gotErr := SearchIndexed(context.Background(), grid, state, start, goal, manhattan, options...)
+		want, wantErr := Search(context.Background(), grid, start, goal, manhattan, options...)
+		if gotErr != wantErr || got.Found != want.Found || got.TotalCost != want.TotalCost ||
+			got.ExpandedNodes != want.ExpandedNodes || !reflect.DeepEqual(got.Path, want.Path) {
+			t.Fatalf("query %d from %v to %v: got %v %+v, want %v %+v", query, start, goal, gotErr, got, wantErr, want)
+		}
+		if state.generation == 2 && query > 100 {
+			wrapped = true
+		}
+	}
+	if !wrapped {
+		t.Fatal("the generation counter never wrapped")
+	}
+}
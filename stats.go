@@ -0,0 +1,30 @@
+package astar
+
+import "time"
+
+// Statistics describes the work done by a search.
+type Statistics struct {
+	// GeneratedNodes counts the neighbor proposals evaluated by the workers.
+	GeneratedNodes int
+	// Relaxations counts proposals that improved a node's g-score.
+	Relaxations int
+	// DecreaseKeys counts relaxations that updated a node already open.
+	DecreaseKeys int
+	// MaxFrontierSize is the largest size reached by the open set.
+	MaxFrontierSize int
+	// Reopenings counts cheaper paths found to nodes that were already
+	// closed. Closed nodes are never reopened, so a non-zero value means the
+	// heuristic is inconsistent and TotalCost may not be optimal.
+	Reopenings int
+	// WallTime is the elapsed time of the search.
+	WallTime time.Duration
+	// OrchestratorTime is the part of WallTime the orchestrator spent working
+	// rather than waiting for workers.
+	OrchestratorTime time.Duration
+	// WorkerTime is the time workers spent evaluating proposals, summed over
+	// all workers.
+	WorkerTime time.Duration
+	// QueueWait is the time expand tasks waited for a free worker, summed
+	// over all tasks.
+	QueueWait time.Duration
+}
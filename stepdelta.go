@@ -0,0 +1,84 @@
+package astar
+
+// StepDelta lists what a single Step changed in the search. The popped node
+// is StepSnapshot.Current.
+type StepDelta[NodeType comparable] struct {
+	// Added lists the nodes that entered the open set.
+	Added []StepNode[NodeType]
+	// Updated lists open nodes that got a cheaper path.
+	Updated []StepNode[NodeType]
+	// Closed lists the nodes that were closed.
+	Closed []NodeType
+	// Invalidated lists the nodes forgotten because of graph edits made
+	// since the previous step, see Stepper.InvalidateNode. They are neither
+	// open nor closed unless they also appear in Added or Closed.
+	Invalidated []NodeType
+}
+
+// StepNode describes a node reached by the search.
+type StepNode[NodeType comparable] struct {
+	Node NodeType
+	// Parent is the predecessor of Node on its best known path, valid when
+	// HasParent is set. Only the start node has no parent.
+	Parent    NodeType
+	HasParent bool
+	GScore    float64
+	FCost     float64
+}
+
+// WithDeltaSnapshots makes Stepper.Step leave the Open, Closed and CameFrom
+// maps of its snapshots nil and report only StepSnapshot.Delta, which keeps
+// each step proportional to the work it did. Stepper.Snapshot still returns
+// the full state.
+func WithDeltaSnapshots() Option {
+	return func(options *Options) { options.DeltaSnapshots = true }
+}
+
+// deltaRecorder collects the StepDelta of the current step from tracer
+// events.
+type deltaRecorder[NodeType comparable] struct {
+	NopTracer[NodeType]
+	state       nodeState[NodeType]
+	delta       StepDelta[NodeType]
+	relaxations []RelaxEvent[NodeType]
+	// scores holds the scores of every node reached so far.
+	scores map[NodeType]NodeScore
+}
+
+func (r *deltaRecorder[NodeType]) OnPush(node NodeType, gScore, fCost float64) {
+	r.scores[node] = NodeScore{GScore: gScore, HScore: fCost - gScore, FCost: fCost}
+	parent, hasParent := r.state.parent(node)
+	r.delta.Added = append(r.delta.Added, StepNode[NodeType]{
+		Node:      node,
+		Parent:    parent,
+		HasParent: hasParent,
+		GScore:    gScore,
+		FCost:     fCost,
+	})
+}
+
+func (r *deltaRecorder[NodeType]) OnPop(node NodeType, gScore, fCost float64) {
+	r.delta.Closed = append(r.delta.Closed, node)
+}
+
+func (r *deltaRecorder[NodeType]) OnRelax(event RelaxEvent[NodeType]) {
+	r.relaxations = append(r.relaxations, event)
+	if event.Outcome != RelaxImproved {
+		return
+	}
+	r.scores[event.To] = NodeScore{GScore: event.GScore, HScore: event.HScore, FCost: event.FCost}
+	r.delta.Updated = append(r.delta.Updated, StepNode[NodeType]{
+		Node:      event.To,
+		Parent:    event.From,
+		HasParent: true,
+		GScore:    event.GScore,
+		FCost:     event.FCost,
+	})
+}
+
+// take returns the delta and the relaxations recorded since the last call.
+func (r *deltaRecorder[NodeType]) take() (*StepDelta[NodeType], []RelaxEvent[NodeType]) {
+	delta, relaxations := r.delta, r.relaxations
+	r.delta, r.relaxations = StepDelta[NodeType]{}, nil
+	return &delta, relaxations
+}
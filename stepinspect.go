@@ -0,0 +1,58 @@
+package astar
+
+import "sort"
+
+// NodeScore holds the scores of a node reached by the search. FCost is
+// GScore plus HScore.
+type NodeScore struct {
+	GScore float64
+	HScore float64
+	FCost  float64
+}
+
+// FrontierEntry is an open node in the order the search will expand it.
+type FrontierEntry[NodeType comparable] struct {
+	Node NodeType
+	NodeScore
+	// Sequence is the insertion order used by the tie-breaking policies.
+	Sequence uint64
+}
+
+// WithFrontierSize makes every StepSnapshot list the first n open nodes in
+// the order they will be expanded, which shows why one node is chosen before
+// another. Nodes with equal f-cost are listed in tie-breaking order, which
+// is unspecified under TieBreakNone. Building the list costs
+// O(open nodes × log n) per step, so it is off by default.
+func WithFrontierSize(n int) Option {
+	return func(options *Options) { options.FrontierSize = n }
+}
+
+// frontier returns the first n open items in expansion order.
+func (o *orchestrator[NodeType]) frontier(n int) []FrontierEntry[NodeType] {
+	if n <= 0 || o.openSet.Len() == 0 {
+		return nil
+	}
+	// top stays sorted and never grows beyond n items.
+	top := make([]*PriorityQueueItem[NodeType], 0, min(n, o.openSet.Len())+1)
+	for item := range o.openSet.All() {
+		if len(top) == n && !o.less(item, top[n-1]) {
+			continue
+		}
+		position := sort.Search(len(top), func(i int) bool { return o.less(item, top[i]) })
+		top = append(top, nil)
+		copy(top[position+1:], top[position:])
+		top[position] = item
+		if len(top) > n {
+			top = top[:n]
+		}
+	}
+	entries := make([]FrontierEntry[NodeType], len(top))
+	for i, item := range top {
+		entries[i] = FrontierEntry[NodeType]{
+			Node:      item.Node,
+			NodeScore: NodeScore{GScore: item.GScore, HScore: item.FCost - item.GScore, FCost: item.FCost},
+			Sequence:  item.sequence,
+		}
+	}
+	return entries
+}
@@ -1,9 +1,13 @@
 package astar
 
 import (
-	"container/heap"
 	"context"
+	"errors"
+	"iter"
+	"maps"
 	"runtime"
+	"sync"
+	"time"
 )
 
 // StepSnapshot exposes the per-iteration state of the search
@@ -16,27 +20,69 @@ type StepSnapshot[NodeType comparable] struct {
 	Found     bool
 	Path      []NodeType
 	StepIndex int
+	// CurrentScore holds the g, h and f values of Current.
+	CurrentScore NodeScore
+	// Delta lists the changes made by the step.
+	Delta *StepDelta[NodeType]
+	// Scores holds the g, h and f values of every node reached so far. It is
+	// nil in delta mode, like Open.
+	Scores map[NodeType]NodeScore
+	// Frontier lists the first open nodes in expansion order, see
+	// WithFrontierSize.
+	Frontier []FrontierEntry[NodeType]
+	// Relaxations lists the neighbor proposals handled by the step, with
+	// their outcome.
+	Relaxations []RelaxEvent[NodeType]
 }
 
 // Stepper provides a step-by-step orchestrator over the concurrent workers
 type Stepper[NodeType comparable] struct {
-	ctx       context.Context
-	cancel    context.CancelFunc
-	graph     Graph[NodeType]
-	goal      NodeType
-	heuristic Heuristic[NodeType]
+	// mu serializes the public methods, which may be called from several
+	// goroutines.
+	mu sync.Mutex
+	// parent is cancelled by Close; ctx is cancelled when the search
+	// finishes and replaced when StepBack revives it.
+	parent      context.Context
+	closeParent context.CancelFunc
+	ctx         context.Context
+	cancel      context.CancelFunc
+
+	orchestrator *orchestrator[NodeType]
+	state        *mapState[NodeType]
+	// ownedPool is the pool started by NewStepper, nil when the pool
+	// belongs to an Engine.
+	ownedPool *workerPool[NodeType]
 	workers   int
 
-	openSet    PriorityQueue[NodeType]
-	openSetMap map[NodeType]*PriorityQueueItem[NodeType]
-	closedSet  map[NodeType]bool
-	cameFrom   map[NodeType]NodeType
-	gScore     map[NodeType]float64
+	// recorder collects the delta of each step; deltaOnly is set by
+	// WithDeltaSnapshots.
+	recorder     *deltaRecorder[NodeType]
+	deltaOnly    bool
+	frontierSize int
+	current      NodeType
+
+	// journal and history let StepBack undo steps; both stay empty unless
+	// WithHistoryLimit is given.
+	journal      *undoLog[NodeType]
+	history      []stepRecord[NodeType]
+	historyLimit int
+
+	breakpoints      []breakpointEntry[NodeType]
+	nextBreakpointID int
+
+	// subscribersMu guards subscribers separately from mu so that an
+	// observer can unsubscribe while being notified.
+	subscribersMu    sync.Mutex
+	subscribers      []subscriber[NodeType]
+	nextSubscriberID int
 
-	expandCh chan ExpandTask[NodeType]
-	relaxCh  chan RelaxProposal[NodeType]
+	// microEvents holds the events of the last step not yet returned by
+	// MicroStep.
+	microEvents []MicroEvent[NodeType]
 
 	stepCount int
+	elapsed   time.Duration
+	started   bool
 	done      bool
 	found     bool
 }
@@ -50,160 +96,234 @@ func NewStepper[NodeType comparable](
 	heuristic Heuristic[NodeType],
 	options ...Option,
 ) *Stepper[NodeType] {
-	// options
-	opts := Options{NumberOfWorkers: runtime.NumCPU()}
-	for _, o := range options {
-		o(&opts)
-	}
+	opts := applyOptions(defaultOptions(), options)
+	pool := newWorkerPool[NodeType](opts.NumberOfWorkers)
+	s := newStepper(parent, graph, pool, startNode, goalNode, heuristic, opts)
+	s.ownedPool = pool
+	// Stop the workers of a stepper that is dropped without Close.
+	runtime.AddCleanup(s, (*workerPool[NodeType]).close, pool)
+	return s
+}
 
+func newStepper[NodeType comparable](
+	parent context.Context,
+	graph Graph[NodeType],
+	pool *workerPool[NodeType],
+	startNode NodeType,
+	goalNode NodeType,
+	heuristic Heuristic[NodeType],
+	opts Options,
+) *Stepper[NodeType] {
+	parent, closeParent := context.WithCancel(parent)
 	ctx, cancel := context.WithCancel(parent)
-	s := &Stepper[NodeType]{
+	state := newMapState[NodeType]()
+	o := newOrchestrator(graph, state, pool, startNode, goalNode, heuristic, opts)
+	recorder := &deltaRecorder[NodeType]{state: state, scores: map[NodeType]NodeScore{}}
+	o.tracer = withTracer[NodeType](o.tracer, recorder)
+	if opts.HistoryLimit != 0 {
+		o.journal = &undoLog[NodeType]{state: state, scores: recorder.scores}
+	}
+	return &Stepper[NodeType]{
+		parent: parent, closeParent: closeParent,
 		ctx: ctx, cancel: cancel,
-		graph: graph, goal: goalNode, heuristic: heuristic,
-		workers:    opts.NumberOfWorkers,
-		openSet:    make(PriorityQueue[NodeType], 0),
-		openSetMap: make(map[NodeType]*PriorityQueueItem[NodeType]),
-		closedSet:  make(map[NodeType]bool),
-		cameFrom:   make(map[NodeType]NodeType),
-		gScore:     map[NodeType]float64{startNode: 0},
-		expandCh:   make(chan ExpandTask[NodeType]),
-		relaxCh:    make(chan RelaxProposal[NodeType]),
-	}
-
-	heap.Init(&s.openSet)
-	startItem := &PriorityQueueItem[NodeType]{Node: startNode, GScore: 0, FCost: heuristic(startNode, goalNode)}
-	heap.Push(&s.openSet, startItem)
-	s.openSetMap[startNode] = startItem
-
-	// start workers
-	for i := 0; i < s.workers; i++ {
-		go func() {
-			for {
-				select {
-				case <-s.ctx.Done():
-					return
-				case task := <-s.expandCh:
-					tentativeG := task.CurrentGScore + task.Neighbor.Cost
-					f := tentativeG + task.HeuristicFunc(task.Neighbor.ID, task.GoalNode)
-					s.relaxCh <- RelaxProposal[NodeType]{
-						FromNode: task.FromNode,
-						ToNode:   task.Neighbor.ID,
-						GScore:   tentativeG,
-						FCost:    f,
-					}
-				}
-			}
-		}()
+		orchestrator: o,
+		state:        state,
+		workers:      opts.NumberOfWorkers,
+		recorder:     recorder,
+		deltaOnly:    opts.DeltaSnapshots,
+		frontierSize: opts.FrontierSize,
+		journal:      o.journal,
+		historyLimit: opts.HistoryLimit,
 	}
-
-	return s
 }
 
-// Close stops the workers
+// Close stops the workers. A stepper also stops them by itself once the
+// search is done or fails. Close interrupts a step in progress in another
+// goroutine, and a closed stepper cannot step again.
 func (s *Stepper[NodeType]) Close() {
+	s.closeParent()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.close()
+}
+
+// close releases the context and the workers of the current search.
+func (s *Stepper[NodeType]) close() {
 	if s.cancel != nil {
 		s.cancel()
 	}
+	if s.ownedPool != nil {
+		s.ownedPool.close()
+	}
+}
+
+// Statistics reports the work done by the steps taken so far. WallTime is
+// the time spent inside Step.
+func (s *Stepper[NodeType]) Statistics() Statistics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.statistics()
+}
+
+func (s *Stepper[NodeType]) statistics() Statistics {
+	stats := s.orchestrator.stats
+	stats.WallTime = s.elapsed
+	return stats
 }
 
 // Step advances the search by one node expansion and returns a snapshot
 func (s *Stepper[NodeType]) Step() (StepSnapshot[NodeType], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wasDone := s.done
+	snapshot, err := s.advance()
+	s.microEvents = s.microEvents[:0]
+	s.complete(&snapshot)
+	if !wasDone {
+		s.notify(snapshot)
+	}
+	return snapshot, err
+}
+
+// Steps returns an iterator over the remaining steps of the search, for use
+// with range:
+//
+//	for snapshot, err := range stepper.Steps() {
+//		...
+//	}
+//
+// The iteration ends after the step that finishes the search or fails.
+// Leaving the loop early closes the stepper, so no Close call is needed in
+// either case.
+func (s *Stepper[NodeType]) Steps() iter.Seq2[StepSnapshot[NodeType], error] {
+	return func(yield func(StepSnapshot[NodeType], error) bool) {
+		defer s.Close()
+		for {
+			snapshot, err := s.Step()
+			if !yield(snapshot, err) || err != nil || snapshot.Done {
+				return
+			}
+		}
+	}
+}
+
+// advance runs one step and returns its snapshot without the copies of the
+// whole state, which complete adds.
+func (s *Stepper[NodeType]) advance() (StepSnapshot[NodeType], error) {
+	stepStart := time.Now()
+	defer func() { s.elapsed += time.Since(stepStart) }()
+
 	if s.done {
-		return StepSnapshot[NodeType]{
-			Done:      true,
-			Found:     s.found,
-			Open:      copyBoolMap(s.openSetToBoolMap()),
-			Closed:    copyBoolMap(s.closedSet),
-			CameFrom:  copyCameFrom(s.cameFrom),
-			Path:      nil,
-			StepIndex: s.stepCount,
-		}, nil
-	}
-	if s.openSet.Len() == 0 {
-		s.done = true
-		return StepSnapshot[NodeType]{
-			Done:      true,
-			Found:     false,
-			Open:      copyBoolMap(s.openSetToBoolMap()),
-			Closed:    copyBoolMap(s.closedSet),
-			CameFrom:  copyCameFrom(s.cameFrom),
-			StepIndex: s.stepCount,
-		}, nil
+		return s.stepSnapshot(s.current, nil), nil
 	}
+	record := s.beginRecord()
+	snapshot, err := s.step()
+	s.endRecord(record, snapshot)
+	return snapshot, err
+}
 
-	s.stepCount++
-	currentItem := heap.Pop(&s.openSet).(*PriorityQueueItem[NodeType])
-	current := currentItem.Node
-	delete(s.openSetMap, current)
-	if s.closedSet[current] {
-		return s.Step()
+// step runs one expansion for Step.
+func (s *Stepper[NodeType]) step() (StepSnapshot[NodeType], error) {
+	if !s.started {
+		s.started = true
+		s.orchestrator.logStart(s.ctx)
+		if err := s.orchestrator.begin(); err != nil {
+			s.finish(false, 0, err)
+			return StepSnapshot[NodeType]{Done: true, Found: false, StepIndex: s.stepCount}, err
+		}
 	}
-	s.closedSet[current] = true
 
-	if current == s.goal {
-		s.done = true
+	currentItem, outcome, err := s.orchestrator.expandNext(s.ctx)
+	if errors.Is(err, ErrBudgetExceeded) {
+		partial := s.orchestrator.partialResult()
+		s.finish(false, partial.TotalCost, err)
+		return s.stepSnapshot(s.current, partial.Path), err
+	}
+	if err != nil {
+		s.finish(false, 0, err)
+		return StepSnapshot[NodeType]{Done: true, Found: false, StepIndex: s.stepCount}, err
+	}
+	if outcome == expansionExhausted {
+		s.finish(false, 0, ErrNoPath)
+		return s.stepSnapshot(s.current, nil), nil
+	}
+
+	s.stepCount++
+	s.current = currentItem.Node
+	if outcome == expansionFound {
 		s.found = true
-		return StepSnapshot[NodeType]{
-			Current:   current,
-			Open:      copyBoolMap(s.openSetToBoolMap()),
-			Closed:    copyBoolMap(s.closedSet),
-			CameFrom:  copyCameFrom(s.cameFrom),
-			Done:      true,
-			Found:     true,
-			Path:      reconstructPath(s.cameFrom, current, inferStartFromCameFrom(s.cameFrom, current)),
-			StepIndex: s.stepCount,
-		}, nil
-	}
-
-	neighbors := s.graph.Neighbors(current)
-	for _, nb := range neighbors {
-		s.expandCh <- ExpandTask[NodeType]{
-			FromNode:      current,
-			Neighbor:      nb,
-			CurrentGScore: currentItem.GScore,
-			GoalNode:      s.goal,
-			HeuristicFunc: s.heuristic,
-		}
+		s.finish(true, currentItem.GScore, nil)
+		return s.stepSnapshot(s.current, s.orchestrator.path(s.current)), nil
 	}
-	for i := 0; i < len(neighbors); i++ {
-		select {
-		case <-s.ctx.Done():
-			s.done = true
-			return StepSnapshot[NodeType]{Done: true, Found: false, StepIndex: s.stepCount}, s.ctx.Err()
-		case p := <-s.relaxCh:
-			if s.closedSet[p.ToNode] {
-				continue
-			}
-			if gPrev, ok := s.gScore[p.ToNode]; !ok || p.GScore < gPrev {
-				s.gScore[p.ToNode] = p.GScore
-				s.cameFrom[p.ToNode] = p.FromNode
-				if it, ok := s.openSetMap[p.ToNode]; !ok {
-					it = &PriorityQueueItem[NodeType]{Node: p.ToNode, GScore: p.GScore, FCost: p.FCost}
-					heap.Push(&s.openSet, it)
-					s.openSetMap[p.ToNode] = it
-				} else if p.FCost < it.FCost {
-					it.GScore = p.GScore
-					it.FCost = p.FCost
-					heap.Fix(&s.openSet, it.IndexInQueue)
-				}
-			}
-		}
+	return s.stepSnapshot(s.current, nil), nil
+}
+
+// Snapshot returns the full state of the search after the last step, with
+// the Open, Closed, CameFrom and Scores maps filled in even in delta mode.
+// Its Delta and Relaxations are nil.
+func (s *Stepper[NodeType]) Snapshot() StepSnapshot[NodeType] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshot()
+}
+
+func (s *Stepper[NodeType]) snapshot() StepSnapshot[NodeType] {
+	snapshot := StepSnapshot[NodeType]{
+		Current:      s.current,
+		CurrentScore: s.recorder.scores[s.current],
+		Open:         s.openSetToBoolMap(),
+		Closed:       copyBoolMap(s.state.closedSet),
+		CameFrom:     copyCameFrom(s.state.cameFrom),
+		Done:         s.done,
+		Found:        s.found,
+		StepIndex:    s.stepCount,
+		Scores:       maps.Clone(s.recorder.scores),
+		Frontier:     s.orchestrator.frontier(s.frontierSize),
+	}
+	if s.found {
+		snapshot.Path = s.orchestrator.path(s.current)
 	}
+	return snapshot
+}
 
+// stepSnapshot describes the step that just ended by its changes only.
+func (s *Stepper[NodeType]) stepSnapshot(current NodeType, path []NodeType) StepSnapshot[NodeType] {
+	delta, relaxations := s.recorder.take()
 	return StepSnapshot[NodeType]{
-		Current:   current,
-		Open:      copyBoolMap(s.openSetToBoolMap()),
-		Closed:    copyBoolMap(s.closedSet),
-		CameFrom:  copyCameFrom(s.cameFrom),
-		Done:      false,
-		Found:     false,
-		StepIndex: s.stepCount,
-	}, nil
+		Current:      current,
+		CurrentScore: s.recorder.scores[current],
+		Done:         s.done,
+		Found:        s.found,
+		Path:         path,
+		StepIndex:    s.stepCount,
+		Delta:        delta,
+		Relaxations:  relaxations,
+	}
+}
+
+// complete adds the frontier to snapshot and, unless in delta mode, copies
+// of the whole state.
+func (s *Stepper[NodeType]) complete(snapshot *StepSnapshot[NodeType]) {
+	snapshot.Frontier = s.orchestrator.frontier(s.frontierSize)
+	if !s.deltaOnly {
+		snapshot.Open = s.openSetToBoolMap()
+		snapshot.Closed = copyBoolMap(s.state.closedSet)
+		snapshot.CameFrom = copyCameFrom(s.state.cameFrom)
+		snapshot.Scores = maps.Clone(s.recorder.scores)
+	}
+}
+
+// finish marks the search as done and releases the workers.
+func (s *Stepper[NodeType]) finish(found bool, cost float64, err error) {
+	s.orchestrator.logEnd(s.ctx, found, cost, err)
+	s.orchestrator.reportDone(found, cost)
+	s.done = true
+	s.close()
 }
 
 func (s *Stepper[NodeType]) openSetToBoolMap() map[NodeType]bool {
-	m := make(map[NodeType]bool, len(s.openSetMap))
-	for k := range s.openSetMap {
+	m := make(map[NodeType]bool, len(s.state.openSetMap))
+	for k := range s.state.openSetMap {
 		m[k] = true
 	}
 	return m
@@ -229,15 +349,3 @@ func copyCameFrom[T comparable](m map[T]T) map[T]T {
 	}
 	return c
 }
-
-// inferStartFromCameFrom tries to find the start by walking backwards until a node with no predecessor
-func inferStartFromCameFrom[T comparable](came map[T]T, current T) T {
-	cur := current
-	for {
-		prev, ok := came[cur]
-		if !ok {
-			return cur
-		}
-		cur = prev
-	}
-}
@@ -0,0 +1,41 @@
+package astar
+
+import "slices"
+
+// subscriber is an observer added with Subscribe.
+type subscriber[NodeType comparable] struct {
+	id       int
+	observer func(StepSnapshot[NodeType])
+}
+
+// Subscribe calls observer with the snapshot of every step taken from now
+// on, whichever goroutine takes it. Observers are called in the order they
+// subscribed, after the step and before the stepping call returns, so they
+// see the steps in order. They must treat the snapshot as read-only and must
+// not call methods of the stepper other than the returned unsubscribe
+// function. Steps taken by RunUntil and StepN are passed without the copies
+// of the whole state, except the last one.
+func (s *Stepper[NodeType]) Subscribe(observer func(StepSnapshot[NodeType])) (unsubscribe func()) {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	s.nextSubscriberID++
+	id := s.nextSubscriberID
+	s.subscribers = append(s.subscribers, subscriber[NodeType]{id: id, observer: observer})
+	return func() {
+		s.subscribersMu.Lock()
+		defer s.subscribersMu.Unlock()
+		s.subscribers = slices.DeleteFunc(s.subscribers, func(candidate subscriber[NodeType]) bool {
+			return candidate.id == id
+		})
+	}
+}
+
+// notify passes snapshot to the subscribers.
+func (s *Stepper[NodeType]) notify(snapshot StepSnapshot[NodeType]) {
+	s.subscribersMu.Lock()
+	subscribers := slices.Clone(s.subscribers)
+	s.subscribersMu.Unlock()
+	for _, subscriber := range subscribers {
+		subscriber.observer(snapshot)
+	}
+}
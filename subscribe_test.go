@@ -0,0 +1,90 @@
+package astar
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestStepperConcurrentUse steps, snapshots, subscribes and closes a
+// stepper from several goroutines. Run with -race. Observers must see the
+// steps in order, and one that unsubscribes itself must not be called again.
+func TestStepperConcurrentUse(t *testing.T) {
+	grid := newTestGrid(60, 60, 0.2, 1)
+	stepper := NewStepper(context.Background(), grid, testPoint{0, 0}, testPoint{59, 59}, manhattan, WithWorkers(4))
+
+	var ordered sync.Mutex
+	lastIndex, notified := -1, 0
+	stepper.Subscribe(func(snapshot StepSnapshot[testPoint]) {
+		ordered.Lock()
+		defer ordered.Unlock()
+		if snapshot.StepIndex < lastIndex || snapshot.StepIndex == lastIndex && !snapshot.Done {
+			t.Errorf("observer got step %d after step %d", snapshot.StepIndex, lastIndex)
+		}
+		lastIndex = snapshot.StepIndex
+		notified++
+	})
+	var selfCalls atomic.Int32
+	var unsubscribeSelf func()
+	unsubscribeSelf = stepper.Subscribe(func(StepSnapshot[testPoint]) {
+		if selfCalls.Add(1) == 5 {
+			unsubscribeSelf()
+		}
+	})
+
+	stop := make(chan struct{})
+	var wait sync.WaitGroup
+	run := func(work func()) {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					work()
+				}
+			}
+		}()
+	}
+	var steppers sync.WaitGroup
+	for i := range 4 {
+		steppers.Add(1)
+		go func() {
+			defer steppers.Done()
+			for {
+				var snapshot StepSnapshot[testPoint]
+				var err error
+				if i%2 == 0 {
+					snapshot, err = stepper.Step()
+				} else {
+					snapshot, err = stepper.StepN(3)
+				}
+				if err != nil || snapshot.Done {
+					return
+				}
+				if snapshot.StepIndex >= 300 {
+					stepper.Close()
+				}
+			}
+		}()
+	}
+	run(func() { _ = stepper.Snapshot() })
+	run(func() {
+		unsubscribe := stepper.Subscribe(func(StepSnapshot[testPoint]) {})
+		unsubscribe()
+	})
+	steppers.Wait()
+	close(stop)
+	wait.Wait()
+	stepper.Close()
+
+	if notified < 300 {
+		t.Fatalf("observer saw %d steps, want at least 300", notified)
+	}
+	if calls := selfCalls.Load(); calls != 5 {
+		t.Fatalf("self-unsubscribing observer was called %d times, want 5", calls)
+	}
+}
@@ -0,0 +1,88 @@
+package astar
+
+// TieBreak selects how open nodes with equal f-cost are ordered.
+type TieBreak int
+
+const (
+	// TieBreakNone leaves ties in whatever order the open list keeps them.
+	// It is the default.
+	TieBreakNone TieBreak = iota
+	// TieBreakHigherG prefers the node with the larger g-score, i.e. the one
+	// deepest along its path. Remaining ties are broken LIFO.
+	TieBreakHigherG
+	// TieBreakLowerH prefers the node with the smaller heuristic estimate.
+	// Remaining ties are broken LIFO.
+	TieBreakLowerH
+	// TieBreakLIFO prefers the node that entered the open set last.
+	TieBreakLIFO
+	// TieBreakFIFO prefers the node that entered the open set first.
+	TieBreakFIFO
+)
+
+// TieBreakEntry describes an open node to a custom tie-breaking comparator.
+type TieBreakEntry struct {
+	GScore float64
+	HScore float64
+	FCost  float64
+	// Sequence counts insertions into the open set, starting at zero for the
+	// start node.
+	Sequence uint64
+}
+
+// WithTieBreak sets the policy for ordering open nodes with equal f-cost.
+func WithTieBreak(policy TieBreak) Option {
+	return func(options *Options) { options.TieBreak = policy }
+}
+
+// WithTieBreakFunc orders open nodes with equal f-cost using less, which must
+// report whether a should be expanded before b. It overrides WithTieBreak.
+func WithTieBreakFunc(less func(a, b TieBreakEntry) bool) Option {
+	return func(options *Options) { options.TieBreakFunc = less }
+}
+
+// newItemLess returns the open list ordering for the tie-breaking options.
+func newItemLess[NodeType comparable](options Options) itemLess[NodeType] {
+	var tieLess itemLess[NodeType]
+	switch {
+	case options.TieBreakFunc != nil:
+		custom := options.TieBreakFunc
+		tieLess = func(a, b *PriorityQueueItem[NodeType]) bool {
+			return custom(tieBreakEntry(a), tieBreakEntry(b))
+		}
+	case options.TieBreak == TieBreakHigherG:
+		tieLess = func(a, b *PriorityQueueItem[NodeType]) bool {
+			if a.GScore != b.GScore {
+				return a.GScore > b.GScore
+			}
+			return a.sequence > b.sequence
+		}
+	case options.TieBreak == TieBreakLowerH:
+		tieLess = func(a, b *PriorityQueueItem[NodeType]) bool {
+			if hA, hB := a.FCost-a.GScore, b.FCost-b.GScore; hA != hB {
+				return hA < hB
+			}
+			return a.sequence > b.sequence
+		}
+	case options.TieBreak == TieBreakLIFO:
+		tieLess = func(a, b *PriorityQueueItem[NodeType]) bool { return a.sequence > b.sequence }
+	case options.TieBreak == TieBreakFIFO:
+		tieLess = func(a, b *PriorityQueueItem[NodeType]) bool { return a.sequence < b.sequence }
+	default:
+		return lessByFCost[NodeType]
+	}
+	return func(a, b *PriorityQueueItem[NodeType]) bool {
+		if a.FCost != b.FCost {
+			return a.FCost < b.FCost
+		}
+		return tieLess(a, b)
+	}
+}
+
+func tieBreakEntry[NodeType comparable](item *PriorityQueueItem[NodeType]) TieBreakEntry {
+	return TieBreakEntry{
+		GScore:   item.GScore,
+		HScore:   item.FCost - item.GScore,
+		FCost:    item.FCost,
+		Sequence: item.sequence,
+	}
+}
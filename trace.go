@@ -0,0 +1,495 @@
+package astar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"math"
+)
+
+// TraceVersion is the version of the trace format written by TraceWriter.
+// It changes whenever the format does.
+//
+//   - 1: first format.
+//   - 2: non-finite scores are written as the strings "+Inf", "-Inf" and
+//     "NaN".
+const TraceVersion = 2
+
+// oldestTraceVersion is the oldest version ReadTrace accepts.
+const oldestTraceVersion = 1
+
+// TraceKind names the record types of a trace.
+type TraceKind string
+
+const (
+	// TraceHeaderKind marks the first line of a trace, see TraceHeader.
+	TraceHeaderKind TraceKind = "header"
+	// TracePush records a node entering the open set.
+	TracePush TraceKind = "push"
+	// TracePop records a node taken from the open set for expansion.
+	TracePop TraceKind = "pop"
+	// TraceRelax records a neighbor proposal, accepted or not.
+	TraceRelax TraceKind = "relax"
+	// TraceSkip records a popped node that was already closed.
+	TraceSkip TraceKind = "skip"
+	// TraceGoal records the goal being popped; G is the cost of the path.
+	TraceGoal TraceKind = "goal"
+)
+
+// TraceHeader is the first line of a trace.
+type TraceHeader[NodeType comparable] struct {
+	Kind    TraceKind `json:"kind"`
+	Version int       `json:"version"`
+	Start   NodeType  `json:"start"`
+	Goal    NodeType  `json:"goal"`
+}
+
+// TraceEvent is one line of a trace after the header. Node is the pushed,
+// popped or skipped node, or the target of a relaxation. H is F - G. Scores
+// that are not finite, such as the +Inf a heuristic may return for dead
+// ends, are written as strings.
+type TraceEvent[NodeType comparable] struct {
+	Kind TraceKind `json:"kind"`
+	Node NodeType  `json:"node"`
+	// From is the expanded node of a relaxation.
+	From *NodeType `json:"from,omitempty"`
+	G    float64   `json:"g"`
+	H    float64   `json:"h"`
+	F    float64   `json:"f"`
+	// Outcome is the RelaxOutcome of a relaxation, as its String.
+	Outcome string `json:"outcome,omitempty"`
+	// PreviousG is the g-score the target of a relaxation had before it.
+	PreviousG *float64 `json:"prev_g,omitempty"`
+}
+
+func (event TraceEvent[NodeType]) String() string {
+	switch event.Kind {
+	case TraceRelax:
+		var from NodeType
+		if event.From != nil {
+			from = *event.From
+		}
+		return fmt.Sprintf("relax %v -> %v g=%g h=%g f=%g (%s)", from, event.Node, event.G, event.H, event.F, event.Outcome)
+	case TraceSkip:
+		return fmt.Sprintf("skip %v", event.Node)
+	case TraceGoal:
+		return fmt.Sprintf("goal %v cost=%g", event.Node, event.G)
+	default:
+		return fmt.Sprintf("%s %v g=%g h=%g f=%g", event.Kind, event.Node, event.G, event.H, event.F)
+	}
+}
+
+// equal compares two events field by field. NaN scores are equal to each
+// other.
+func (event TraceEvent[NodeType]) equal(other TraceEvent[NodeType]) bool {
+	if event.Kind != other.Kind || event.Node != other.Node || event.Outcome != other.Outcome ||
+		!sameScore(event.G, other.G) || !sameScore(event.H, other.H) || !sameScore(event.F, other.F) {
+		return false
+	}
+	if (event.From == nil) != (other.From == nil) || event.From != nil && *event.From != *other.From {
+		return false
+	}
+	return (event.PreviousG == nil) == (other.PreviousG == nil) &&
+		(event.PreviousG == nil || sameScore(*event.PreviousG, *other.PreviousG))
+}
+
+func sameScore(a, b float64) bool {
+	return a == b || math.IsNaN(a) && math.IsNaN(b)
+}
+
+// traceEventLine is the JSON layout of a TraceEvent.
+type traceEventLine[NodeType comparable] struct {
+	Kind      TraceKind   `json:"kind"`
+	Node      NodeType    `json:"node"`
+	From      *NodeType   `json:"from,omitempty"`
+	G         traceScore  `json:"g"`
+	H         traceScore  `json:"h"`
+	F         traceScore  `json:"f"`
+	Outcome   string      `json:"outcome,omitempty"`
+	PreviousG *traceScore `json:"prev_g,omitempty"`
+}
+
+func (event TraceEvent[NodeType]) MarshalJSON() ([]byte, error) {
+	line := traceEventLine[NodeType]{
+		Kind:    event.Kind,
+		Node:    event.Node,
+		From:    event.From,
+		G:       traceScore(event.G),
+		H:       traceScore(event.H),
+		F:       traceScore(event.F),
+		Outcome: event.Outcome,
+	}
+	if event.PreviousG != nil {
+		previous := traceScore(*event.PreviousG)
+		line.PreviousG = &previous
+	}
+	return json.Marshal(line)
+}
+
+func (event *TraceEvent[NodeType]) UnmarshalJSON(data []byte) error {
+	var line traceEventLine[NodeType]
+	if err := json.Unmarshal(data, &line); err != nil {
+		return err
+	}
+	*event = TraceEvent[NodeType]{
+		Kind:    line.Kind,
+		Node:    line.Node,
+		From:    line.From,
+		G:       float64(line.G),
+		H:       float64(line.H),
+		F:       float64(line.F),
+		Outcome: line.Outcome,
+	}
+	if line.PreviousG != nil {
+		previous := float64(*line.PreviousG)
+		event.PreviousG = &previous
+	}
+	return nil
+}
+
+// traceScore is a float64 that encodes infinities and NaN as strings,
+// which JSON numbers cannot represent.
+type traceScore float64
+
+func (score traceScore) MarshalJSON() ([]byte, error) {
+	switch value := float64(score); {
+	case math.IsInf(value, 1):
+		return []byte(`"+Inf"`), nil
+	case math.IsInf(value, -1):
+		return []byte(`"-Inf"`), nil
+	case math.IsNaN(value):
+		return []byte(`"NaN"`), nil
+	default:
+		return json.Marshal(value)
+	}
+}
+
+func (score *traceScore) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case `"+Inf"`:
+		*score = traceScore(math.Inf(1))
+	case `"-Inf"`:
+		*score = traceScore(math.Inf(-1))
+	case `"NaN"`:
+		*score = traceScore(math.NaN())
+	default:
+		var value float64
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*score = traceScore(value)
+	}
+	return nil
+}
+
+// TraceWriter is a Tracer that writes the events of a search as JSON Lines:
+// a TraceHeader followed by one TraceEvent per line. Nodes are encoded with
+// encoding/json. Attach it with WithTracer to Search or a Stepper; use one
+// writer per search. Graph edits made on a Stepper are not recorded.
+type TraceWriter[NodeType comparable] struct {
+	encoder *json.Encoder
+	err     error
+}
+
+// NewTraceWriter writes the trace header for a search from start to goal
+// and returns the tracer that writes the rest.
+func NewTraceWriter[NodeType comparable](writer io.Writer, start, goal NodeType) *TraceWriter[NodeType] {
+	w := &TraceWriter[NodeType]{encoder: json.NewEncoder(writer)}
+	w.write(TraceHeader[NodeType]{Kind: TraceHeaderKind, Version: TraceVersion, Start: start, Goal: goal})
+	return w
+}
+
+// Err returns the first error met while writing. Writing stops after it.
+func (w *TraceWriter[NodeType]) Err() error {
+	return w.err
+}
+
+func (w *TraceWriter[NodeType]) write(record any) {
+	if w.err == nil {
+		w.err = w.encoder.Encode(record)
+	}
+}
+
+func (w *TraceWriter[NodeType]) OnPush(node NodeType, gScore, fCost float64) {
+	w.write(TraceEvent[NodeType]{Kind: TracePush, Node: node, G: gScore, H: fCost - gScore, F: fCost})
+}
+
+func (w *TraceWriter[NodeType]) OnPop(node NodeType, gScore, fCost float64) {
+	w.write(TraceEvent[NodeType]{Kind: TracePop, Node: node, G: gScore, H: fCost - gScore, F: fCost})
+}
+
+func (w *TraceWriter[NodeType]) OnRelax(event RelaxEvent[NodeType]) {
+	record := TraceEvent[NodeType]{
+		Kind:    TraceRelax,
+		Node:    event.To,
+		From:    &event.From,
+		G:       event.GScore,
+		H:       event.HScore,
+		F:       event.FCost,
+		Outcome: event.Outcome.String(),
+	}
+	if event.HadGScore {
+		record.PreviousG = &event.PreviousGScore
+	}
+	w.write(record)
+}
+
+func (w *TraceWriter[NodeType]) OnSkipClosed(node NodeType) {
+	w.write(TraceEvent[NodeType]{Kind: TraceSkip, Node: node})
+}
+
+func (w *TraceWriter[NodeType]) OnGoal(node NodeType, cost float64) {
+	w.write(TraceEvent[NodeType]{Kind: TraceGoal, Node: node, G: cost})
+}
+
+// Trace is a trace read back by ReadTrace.
+type Trace[NodeType comparable] struct {
+	Header TraceHeader[NodeType]
+	Events []TraceEvent[NodeType]
+}
+
+// ReadTrace reads a trace written by TraceWriter. It returns an error
+// wrapping ErrInvalidTrace if the header is missing, the version is not
+// supported or a line is malformed.
+func ReadTrace[NodeType comparable](reader io.Reader) (*Trace[NodeType], error) {
+	decoder := json.NewDecoder(reader)
+	trace := &Trace[NodeType]{}
+	if err := decoder.Decode(&trace.Header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidTrace, err)
+	}
+	if trace.Header.Kind != TraceHeaderKind {
+		return nil, fmt.Errorf("%w: first line is %q, not a header", ErrInvalidTrace, trace.Header.Kind)
+	}
+	if trace.Header.Version < oldestTraceVersion || trace.Header.Version > TraceVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidTrace, trace.Header.Version)
+	}
+	for line := 2; ; line++ {
+		var event TraceEvent[NodeType]
+		err := decoder.Decode(&event)
+		if errors.Is(err, io.EOF) {
+			return trace, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidTrace, line, err)
+		}
+		switch event.Kind {
+		case TracePush, TracePop, TraceSkip, TraceGoal:
+		case TraceRelax:
+			if event.From == nil {
+				return nil, fmt.Errorf("%w: line %d: relax without from", ErrInvalidTrace, line)
+			}
+			if _, known := parseRelaxOutcome(event.Outcome); !known {
+				return nil, fmt.Errorf("%w: line %d: unknown outcome %q", ErrInvalidTrace, line, event.Outcome)
+			}
+		default:
+			return nil, fmt.Errorf("%w: line %d: unknown kind %q", ErrInvalidTrace, line, event.Kind)
+		}
+		trace.Events = append(trace.Events, event)
+	}
+}
+
+// parseRelaxOutcome is the inverse of RelaxOutcome.String.
+func parseRelaxOutcome(name string) (RelaxOutcome, bool) {
+	for outcome := RelaxOpened; outcome <= RelaxRejectedBlocked; outcome++ {
+		if outcome.String() == name {
+			return outcome, true
+		}
+	}
+	return 0, false
+}
+
+// TraceDivergence is the first difference between two traces, reported by
+// DiffTraces.
+type TraceDivergence[NodeType comparable] struct {
+	// Index is the position of the differing event in both traces.
+	Index int
+	// Step is the number of nodes expanded before the divergence.
+	Step int
+	// A and B are the differing events; one of them is nil when its trace
+	// ended first.
+	A, B *TraceEvent[NodeType]
+}
+
+func (d *TraceDivergence[NodeType]) String() string {
+	describe := func(event *TraceEvent[NodeType]) string {
+		if event == nil {
+			return "end of trace"
+		}
+		return event.String()
+	}
+	return fmt.Sprintf("event %d (step %d): %s != %s", d.Index, d.Step, describe(d.A), describe(d.B))
+}
+
+// DiffTraces returns the first event where a and b differ, or nil if their
+// events are identical. Headers are not compared.
+func DiffTraces[NodeType comparable](a, b *Trace[NodeType]) *TraceDivergence[NodeType] {
+	step := 0
+	for index := 0; index < max(len(a.Events), len(b.Events)); index++ {
+		if index >= len(a.Events) || index >= len(b.Events) || !a.Events[index].equal(b.Events[index]) {
+			divergence := &TraceDivergence[NodeType]{Index: index, Step: step}
+			if index < len(a.Events) {
+				divergence.A = &a.Events[index]
+			}
+			if index < len(b.Events) {
+				divergence.B = &b.Events[index]
+			}
+			return divergence
+		}
+		if a.Events[index].Kind == TracePop {
+			step++
+		}
+	}
+	return nil
+}
+
+// TraceReplay steps through a recorded trace with the same snapshots as a
+// Stepper, without a graph or workers. Each step runs from a pop to the
+// next one. Frontier is not filled in, and Scores holds the values seen in
+// the trace.
+type TraceReplay[NodeType comparable] struct {
+	trace    *Trace[NodeType]
+	position int
+
+	open     map[NodeType]bool
+	closed   map[NodeType]bool
+	cameFrom map[NodeType]NodeType
+	scores   map[NodeType]NodeScore
+
+	current   NodeType
+	stepCount int
+	done      bool
+	found     bool
+}
+
+// NewTraceReplay returns a replay positioned before the first step of
+// trace.
+func NewTraceReplay[NodeType comparable](trace *Trace[NodeType]) *TraceReplay[NodeType] {
+	return &TraceReplay[NodeType]{
+		trace:    trace,
+		open:     map[NodeType]bool{},
+		closed:   map[NodeType]bool{},
+		cameFrom: map[NodeType]NodeType{},
+		scores:   map[NodeType]NodeScore{},
+	}
+}
+
+// Step replays the next expansion and returns its snapshot. Once the trace
+// is over it returns a snapshot with Done set.
+func (r *TraceReplay[NodeType]) Step() (StepSnapshot[NodeType], error) {
+	events := r.trace.Events
+	delta := &StepDelta[NodeType]{}
+	var relaxations []RelaxEvent[NodeType]
+	popped := false
+	for ; r.position < len(events); r.position++ {
+		event := events[r.position]
+		if popped && (event.Kind == TracePop || event.Kind == TraceSkip) {
+			break
+		}
+		switch event.Kind {
+		case TracePush:
+			r.open[event.Node] = true
+			r.scores[event.Node] = NodeScore{GScore: event.G, HScore: event.H, FCost: event.F}
+			delta.Added = append(delta.Added, StepNode[NodeType]{Node: event.Node, GScore: event.G, FCost: event.F})
+		case TracePop:
+			popped = true
+			delete(r.open, event.Node)
+			r.closed[event.Node] = true
+			r.current = event.Node
+			r.stepCount++
+			delta.Closed = append(delta.Closed, event.Node)
+		case TraceSkip:
+			delete(r.open, event.Node)
+		case TraceGoal:
+			r.found = true
+		case TraceRelax:
+			outcome, _ := parseRelaxOutcome(event.Outcome)
+			relax := RelaxEvent[NodeType]{
+				From:    *event.From,
+				To:      event.Node,
+				GScore:  event.G,
+				HScore:  event.H,
+				FCost:   event.F,
+				Outcome: outcome,
+			}
+			if event.PreviousG != nil {
+				relax.PreviousGScore, relax.HadGScore = *event.PreviousG, true
+			}
+			relaxations = append(relaxations, relax)
+			if !outcome.Accepted() {
+				continue
+			}
+			r.cameFrom[event.Node] = *event.From
+			r.scores[event.Node] = NodeScore{GScore: event.G, HScore: event.H, FCost: event.F}
+			if outcome == RelaxImproved {
+				delta.Updated = append(delta.Updated, StepNode[NodeType]{
+					Node: event.Node, Parent: *event.From, HasParent: true, GScore: event.G, FCost: event.F,
+				})
+			}
+		}
+	}
+	// Pushes come before the relaxation that caused them.
+	for i := range delta.Added {
+		delta.Added[i].Parent, delta.Added[i].HasParent = r.cameFrom[delta.Added[i].Node]
+	}
+	// Like Stepper, an exhausted search is done on the step after its last
+	// expansion.
+	if r.found || !popped {
+		r.done = true
+	}
+	snapshot := r.Snapshot()
+	snapshot.Delta = delta
+	snapshot.Relaxations = relaxations
+	return snapshot, nil
+}
+
+// Steps returns an iterator over the remaining steps of the replay, like
+// Stepper.Steps.
+func (r *TraceReplay[NodeType]) Steps() iter.Seq2[StepSnapshot[NodeType], error] {
+	return func(yield func(StepSnapshot[NodeType], error) bool) {
+		for !r.done {
+			snapshot, err := r.Step()
+			if !yield(snapshot, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Snapshot returns the full state after the last replayed step.
+func (r *TraceReplay[NodeType]) Snapshot() StepSnapshot[NodeType] {
+	snapshot := StepSnapshot[NodeType]{
+		Current:      r.current,
+		CurrentScore: r.scores[r.current],
+		Open:         maps.Clone(r.open),
+		Closed:       maps.Clone(r.closed),
+		CameFrom:     maps.Clone(r.cameFrom),
+		Done:         r.done,
+		Found:        r.found,
+		StepIndex:    r.stepCount,
+		Scores:       maps.Clone(r.scores),
+	}
+	if r.found {
+		snapshot.Path = r.path()
+	}
+	return snapshot
+}
+
+// path follows cameFrom back from the current node to the start.
+func (r *TraceReplay[NodeType]) path() []NodeType {
+	path := []NodeType{r.current}
+	for node := r.current; node != r.trace.Header.Start; {
+		parent, known := r.cameFrom[node]
+		if !known {
+			break
+		}
+		path = append(path, parent)
+		node = parent
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
@@ -0,0 +1,109 @@
+package astar
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// deadEndHeuristic returns +Inf on the bottom row, left of the goal, as a
+// heuristic that knows those cells lead nowhere might.
+func deadEndHeuristic(from, to testPoint) float64 {
+	if from[1] == to[1] && from[0] < to[0]-1 {
+		return math.Inf(1)
+	}
+	return manhattan(from, to)
+}
+
+func TestTraceReplayMatchesStepper(t *testing.T) {
+	grid := newTestGrid(15, 15, 0.2, 6)
+	start, goal := testPoint{0, 0}, testPoint{14, 14}
+	var written bytes.Buffer
+	writer := NewTraceWriter(&written, start, goal)
+	stepper := NewStepper(context.Background(), grid, start, goal, deadEndHeuristic,
+		WithWorkers(2), WithDeterministic(), WithTracer[testPoint](writer))
+	var live []StepSnapshot[testPoint]
+	for snapshot, err := range stepper.Steps() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		live = append(live, snapshot)
+	}
+	if err := writer.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(written.Bytes(), []byte(`"+Inf"`)) {
+		t.Fatal("trace has no infinite score; the test no longer covers them")
+	}
+
+	trace, err := ReadTrace[testPoint](bytes.NewReader(written.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := NewTraceReplay(trace)
+	steps := 0
+	for snapshot, err := range replay.Steps() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := live[steps]
+		if !sameState(snapshot, want) || !reflect.DeepEqual(snapshot.Path, want.Path) ||
+			!reflect.DeepEqual(snapshot.Delta, want.Delta) || !reflect.DeepEqual(snapshot.Relaxations, want.Relaxations) {
+			t.Fatalf("replayed step %d differs from the stepper", steps)
+		}
+		steps++
+	}
+	if steps != len(live) {
+		t.Fatalf("replayed %d steps, want %d", steps, len(live))
+	}
+
+	var again bytes.Buffer
+	if _, err := Search(context.Background(), grid, start, goal, deadEndHeuristic,
+		WithWorkers(2), WithDeterministic(), WithTracer[testPoint](NewTraceWriter(&again, start, goal))); err != nil {
+		t.Fatal(err)
+	}
+	searched, err := ReadTrace[testPoint](&again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if divergence := DiffTraces(trace, searched); divergence != nil {
+		t.Fatalf("Search and Stepper traces differ: %v", divergence)
+	}
+
+	var other bytes.Buffer
+	Search(context.Background(), grid, start, goal, manhattan,
+		WithWorkers(2), WithDeterministic(), WithTracer[testPoint](NewTraceWriter(&other, start, goal)))
+	changed, err := ReadTrace[testPoint](&other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	divergence := DiffTraces(trace, changed)
+	if divergence == nil || divergence.A == nil || divergence.B == nil {
+		t.Fatalf("DiffTraces = %v, want a divergence between two events", divergence)
+	}
+	if divergence.A.equal(*divergence.B) {
+		t.Fatalf("divergence at equal events: %v", divergence)
+	}
+}
+
+func TestTraceScoresRoundTrip(t *testing.T) {
+	previous := math.Inf(-1)
+	from := testPoint{1, 2}
+	event := TraceEvent[testPoint]{
+		Kind: TraceRelax, Node: testPoint{3, 4}, From: &from,
+		G: 1.5, H: math.Inf(1), F: math.NaN(), Outcome: RelaxOpened.String(), PreviousG: &previous,
+	}
+	data, err := event.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded TraceEvent[testPoint]
+	if err := decoded.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.equal(event) {
+		t.Fatalf("decoded %v from %s, want %v", decoded, data, event)
+	}
+}
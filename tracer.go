@@ -0,0 +1,155 @@
+package astar
+
+// Tracer receives the events of a search as they happen. Attach one with
+// WithTracer to instrument Search, an Engine or a Stepper, e.g. to build heat
+// maps or expansion logs. Callbacks run on the orchestrator goroutine, so
+// they must be fast and must not call back into the search.
+//
+// Embed NopTracer to implement only some of the callbacks.
+type Tracer[NodeType comparable] interface {
+	// OnPush is called when node enters the open set.
+	OnPush(node NodeType, gScore, fCost float64)
+	// OnPop is called when node is taken from the open set for expansion.
+	OnPop(node NodeType, gScore, fCost float64)
+	// OnRelax is called for every neighbor proposal, accepted or not.
+	OnRelax(event RelaxEvent[NodeType])
+	// OnSkipClosed is called when a popped node was already closed.
+	OnSkipClosed(node NodeType)
+	// OnGoal is called when the goal is popped, with the cost of the path.
+	OnGoal(node NodeType, cost float64)
+}
+
+// RelaxOutcome tells what the orchestrator did with a neighbor proposal.
+type RelaxOutcome int
+
+const (
+	// RelaxOpened means the proposal was the first path to its node, which
+	// was pushed to the open set.
+	RelaxOpened RelaxOutcome = iota
+	// RelaxImproved means the proposal found a cheaper path to an open node,
+	// whose key was decreased.
+	RelaxImproved
+	// RelaxRejectedClosed means the node was already closed.
+	RelaxRejectedClosed
+	// RelaxRejectedNotBetter means the node already had a path at least as
+	// cheap.
+	RelaxRejectedNotBetter
+	// RelaxRejectedBlocked means the node was made an obstacle with
+	// Stepper.AddObstacle.
+	RelaxRejectedBlocked
+)
+
+// Accepted reports whether the proposal updated the search.
+func (outcome RelaxOutcome) Accepted() bool {
+	return outcome == RelaxOpened || outcome == RelaxImproved
+}
+
+func (outcome RelaxOutcome) String() string {
+	switch outcome {
+	case RelaxOpened:
+		return "opened"
+	case RelaxImproved:
+		return "improved"
+	case RelaxRejectedClosed:
+		return "rejected: closed"
+	case RelaxRejectedNotBetter:
+		return "rejected: not better"
+	case RelaxRejectedBlocked:
+		return "rejected: blocked"
+	default:
+		return "unknown"
+	}
+}
+
+// RelaxEvent describes one neighbor proposal handled by the orchestrator.
+type RelaxEvent[NodeType comparable] struct {
+	From    NodeType
+	To      NodeType
+	GScore  float64
+	HScore  float64
+	FCost   float64
+	Outcome RelaxOutcome
+	// PreviousGScore is the g-score To had before the proposal, valid when
+	// HadGScore is set.
+	PreviousGScore float64
+	HadGScore      bool
+}
+
+// NopTracer implements Tracer with callbacks that do nothing.
+type NopTracer[NodeType comparable] struct{}
+
+func (NopTracer[NodeType]) OnPush(node NodeType, gScore, fCost float64) {}
+func (NopTracer[NodeType]) OnPop(node NodeType, gScore, fCost float64)  {}
+func (NopTracer[NodeType]) OnRelax(event RelaxEvent[NodeType])          {}
+func (NopTracer[NodeType]) OnSkipClosed(node NodeType)                  {}
+func (NopTracer[NodeType]) OnGoal(node NodeType, cost float64)          {}
+
+// WithTracer attaches tracer to the search. The tracer's node type must
+// match the search's, otherwise it is ignored. Several tracers can be
+// attached; they are called in the order they were given.
+func WithTracer[NodeType comparable](tracer Tracer[NodeType]) Option {
+	return func(options *Options) { options.tracers = append(options.tracers, tracer) }
+}
+
+// tracersFor collects the tracers in searchOptions that match NodeType.
+func tracersFor[NodeType comparable](searchOptions Options) Tracer[NodeType] {
+	var tracers multiTracer[NodeType]
+	for _, candidate := range searchOptions.tracers {
+		if tracer, matches := candidate.(Tracer[NodeType]); matches {
+			tracers = append(tracers, tracer)
+		}
+	}
+	switch len(tracers) {
+	case 0:
+		return nil
+	case 1:
+		return tracers[0]
+	default:
+		return tracers
+	}
+}
+
+// withTracer returns a tracer that calls tracer after existing, if any.
+func withTracer[NodeType comparable](existing, tracer Tracer[NodeType]) Tracer[NodeType] {
+	switch existing := existing.(type) {
+	case nil:
+		return tracer
+	case multiTracer[NodeType]:
+		return append(existing[:len(existing):len(existing)], tracer)
+	default:
+		return multiTracer[NodeType]{existing, tracer}
+	}
+}
+
+// multiTracer forwards every event to several tracers.
+type multiTracer[NodeType comparable] []Tracer[NodeType]
+
+func (tracers multiTracer[NodeType]) OnPush(node NodeType, gScore, fCost float64) {
+	for _, tracer := range tracers {
+		tracer.OnPush(node, gScore, fCost)
+	}
+}
+
+func (tracers multiTracer[NodeType]) OnPop(node NodeType, gScore, fCost float64) {
+	for _, tracer := range tracers {
+		tracer.OnPop(node, gScore, fCost)
+	}
+}
+
+func (tracers multiTracer[NodeType]) OnRelax(event RelaxEvent[NodeType]) {
+	for _, tracer := range tracers {
+		tracer.OnRelax(event)
+	}
+}
+
+func (tracers multiTracer[NodeType]) OnSkipClosed(node NodeType) {
+	for _, tracer := range tracers {
+		tracer.OnSkipClosed(node)
+	}
+}
+
+func (tracers multiTracer[NodeType]) OnGoal(node NodeType, cost float64) {
+	for _, tracer := range tracers {
+		tracer.OnGoal(node, cost)
+	}
+}
@@ -1,5 +1,11 @@
 package astar
 
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
 // ExpandTask represents a request from the orchestrator to the workers.
 type ExpandTask[NodeType comparable] struct {
 	FromNode      NodeType
@@ -7,6 +13,17 @@ type ExpandTask[NodeType comparable] struct {
 	CurrentGScore float64
 	GoalNode      NodeType
 	HeuristicFunc Heuristic[NodeType]
+	// NeighborIndex is the position of Neighbor in the list returned by
+	// Graph.Neighbors.
+	NeighborIndex int
+
+	// replyChannel receives the proposal. Each search has its own, which
+	// lets several searches share one pool.
+	replyChannel chan<- RelaxProposal[NodeType]
+	// cancelled is closed when the search no longer waits for the reply.
+	cancelled <-chan struct{}
+	// dispatched is when the orchestrator started offering the task.
+	dispatched time.Time
 }
 
 // RelaxProposal is the worker's suggestion for updating a path
@@ -15,4 +32,86 @@ type RelaxProposal[NodeType comparable] struct {
 	ToNode   NodeType
 	GScore   float64
 	FCost    float64
+	// NeighborIndex is copied from the ExpandTask that produced the proposal.
+	NeighborIndex int
+
+	// err is set when the edge cost is invalid or the heuristic panicked;
+	// the other fields are then meaningless.
+	err error
+	// duration is the time the worker spent on the proposal.
+	duration time.Duration
+	// queueWait is the time the task waited for a free worker.
+	queueWait time.Duration
+}
+
+// workerPool is a set of goroutines that turn expand tasks into relax
+// proposals. It runs until close is called.
+type workerPool[NodeType comparable] struct {
+	expandTaskChannel chan ExpandTask[NodeType]
+	quit              chan struct{}
+	closeOnce         sync.Once
+	workersDone       sync.WaitGroup
+}
+
+// newWorkerPool starts numberOfWorkers workers.
+func newWorkerPool[NodeType comparable](numberOfWorkers int) *workerPool[NodeType] {
+	if numberOfWorkers < 1 {
+		numberOfWorkers = 1
+	}
+	pool := &workerPool[NodeType]{
+		expandTaskChannel: make(chan ExpandTask[NodeType]),
+		quit:              make(chan struct{}),
+	}
+	pool.workersDone.Add(numberOfWorkers)
+	for i := 0; i < numberOfWorkers; i++ {
+		go pool.work()
+	}
+	return pool
+}
+
+func (pool *workerPool[NodeType]) work() {
+	defer pool.workersDone.Done()
+	for {
+		select {
+		case <-pool.quit:
+			return
+		case task := <-pool.expandTaskChannel:
+			proposal := evaluate(task)
+			select {
+			case task.replyChannel <- proposal:
+			case <-task.cancelled:
+			case <-pool.quit:
+				return
+			}
+		}
+	}
+}
+
+// evaluate computes the proposal for one task. A panicking heuristic is
+// reported through the proposal instead of killing the worker.
+func evaluate[NodeType comparable](task ExpandTask[NodeType]) RelaxProposal[NodeType] {
+	started := time.Now()
+	proposal := RelaxProposal[NodeType]{
+		FromNode:      task.FromNode,
+		ToNode:        task.Neighbor.ID,
+		NeighborIndex: task.NeighborIndex,
+		queueWait:     started.Sub(task.dispatched),
+	}
+	if !(task.Neighbor.Cost >= 0) {
+		proposal.err = fmt.Errorf("%w: edge %v -> %v costs %v", ErrNegativeCost, task.FromNode, task.Neighbor.ID, task.Neighbor.Cost)
+		return proposal
+	}
+	h, err := safeHeuristic(task.HeuristicFunc, task.Neighbor.ID, task.GoalNode)
+	proposal.GScore = task.CurrentGScore + task.Neighbor.Cost
+	proposal.FCost = proposal.GScore + h
+	proposal.err = err
+	proposal.duration = time.Since(started)
+	return proposal
+}
+
+// close stops the workers and waits for them to exit. It is safe to call
+// more than once.
+func (pool *workerPool[NodeType]) close() {
+	pool.closeOnce.Do(func() { close(pool.quit) })
+	pool.workersDone.Wait()
 }